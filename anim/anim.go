@@ -0,0 +1,120 @@
+// Package anim plays the sprite animations of the game. The animations are
+// clips in a JSON file, keyed by their names, and an Animator plays one clip
+// at a time.
+package anim
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+)
+
+// Clip is an animation, a sequence of sprites.
+type Clip struct {
+	// Frames are the names of the sprites of the clip, in order.
+	Frames []string `json:"frames"`
+	// Duration is how many frames of the game each sprite is shown for, when
+	// the clip is played at normal speed.
+	Duration int `json:"duration"`
+	// Loop clips start over after the last frame, other clips are played
+	// once and then end.
+	Loop bool `json:"loop"`
+}
+
+// Clips are keyed by the names of the animations.
+type Clips map[string]*Clip
+
+// Load reads the clips from name+".json" in fsys.
+func Load(fsys fs.FS, name string) (Clips, error) {
+	data, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes clips.
+func Parse(data []byte) (Clips, error) {
+	var clips Clips
+	if err := json.Unmarshal(data, &clips); err != nil {
+		return nil, err
+	}
+	for name, c := range clips {
+		if c == nil || len(c.Frames) == 0 {
+			return nil, fmt.Errorf("anim: clip %q has no frames", name)
+		}
+		if c.Duration <= 0 {
+			return nil, fmt.Errorf("anim: clip %q has duration %d", name, c.Duration)
+		}
+	}
+	return clips, nil
+}
+
+// Animator plays a clip. The zero value plays nothing until it is given a
+// clip with Play or Switch.
+type Animator struct {
+	clip  *Clip
+	onEnd func()
+	// frame is the index into the clip's frames, -1 before the first Update.
+	frame int
+	// next counts down the frames of the game until the next frame.
+	next int
+	done bool
+}
+
+// Play starts the clip from the beginning. onEnd is called when a clip that
+// does not loop is over, it may be nil.
+func (a *Animator) Play(clip *Clip, onEnd func()) {
+	*a = Animator{clip: clip, onEnd: onEnd, frame: -1}
+}
+
+// Switch plays another clip from where the current one is. This is for clips
+// that are variations of each other, like a bike seen from the side and from
+// the back. Switching to the clip that is playing does nothing, switching
+// without a clip starts the new one.
+func (a *Animator) Switch(clip *Clip) {
+	if a.clip == nil {
+		a.Play(clip, nil)
+		return
+	}
+	a.clip = clip
+	a.frame = min(a.frame, len(clip.Frames)-1)
+}
+
+// Update advances the animation by one frame of the game. speed is how much
+// faster than normal the clip plays, the animation stands still at speed 0.
+// Update reports whether the animation moved on to its next frame.
+func (a *Animator) Update(speed float64) bool {
+	if a.clip == nil || a.done || speed <= 0 {
+		return false
+	}
+	a.next--
+	if a.next > 0 {
+		return false
+	}
+	a.frame++
+	if a.frame == len(a.clip.Frames) {
+		if !a.clip.Loop {
+			a.done = true
+			if a.onEnd != nil {
+				a.onEnd()
+			}
+			return false
+		}
+		a.frame = 0
+	}
+	a.next = int(math.Round(float64(a.clip.Duration) / speed))
+	return true
+}
+
+// Frame is the name of the sprite to draw. It is the first sprite of the clip
+// before the first Update and the last one after the clip ended.
+func (a *Animator) Frame() string {
+	return a.clip.Frames[min(max(a.frame, 0), len(a.clip.Frames)-1)]
+}
+
+// Done reports whether a clip that does not loop is over.
+func (a *Animator) Done() bool {
+	return a.done
+}
@@ -0,0 +1,91 @@
+// Package atlas reads the sprite atlas of the game. The atlas is a single image
+// that all sprites are packed into, together with a JSON index of the frames,
+// which says where each sprite is in the image. Both are generated from the
+// sprites in the rsc folder by cmd/atlaspack.
+package atlas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+)
+
+type Atlas struct {
+	// Image is the name of the atlas image, without the ".png" extension.
+	Image string `json:"image"`
+	// Frames are keyed by the name of the sprite, which is the name of its
+	// file without the ".png" extension, e.g. "car_3".
+	Frames map[string]Frame `json:"frames"`
+
+	fsys  fs.FS
+	image image.Image
+}
+
+// Frame is where a sprite is in the atlas image, in pixels.
+type Frame struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// Load reads the index of the atlas with the given name from name+".json" in
+// fsys. The atlas image is read from fsys as well, when Cut needs it.
+func Load(fsys fs.FS, name string) (*Atlas, error) {
+	data, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	var a Atlas
+	if err := json.Unmarshal(data, &a); err != nil {
+		return nil, err
+	}
+	for name, f := range a.Frames {
+		if f.W <= 0 || f.H <= 0 {
+			return nil, fmt.Errorf("atlas: frame %q is empty", name)
+		}
+	}
+	a.fsys = fsys
+	return &a, nil
+}
+
+// Frame returns where the sprite with the given name is in the atlas.
+func (a *Atlas) Frame(name string) (Frame, bool) {
+	f, ok := a.Frames[name]
+	return f, ok
+}
+
+// Cut returns the sprite with the given name as a PNG file of its own. This is
+// for drawing the sprite in ways that only work with whole images.
+func (a *Atlas) Cut(name string) ([]byte, error) {
+	f, ok := a.Frames[name]
+	if !ok {
+		return nil, fmt.Errorf("atlas: no frame %q", name)
+	}
+	if a.image == nil {
+		file, err := a.fsys.Open(a.Image + ".png")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		a.image, err = png.Decode(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sub, ok := a.image.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("atlas: cannot cut frames out of %s.png", a.Image)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sub.SubImage(image.Rect(f.X, f.Y, f.X+f.W, f.Y+f.H))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,249 @@
+// Package audio plays what the game wants to be heard in every frame, see
+// sim.Audio. The sounds are the WAV files in the rsc folder.
+package audio
+
+import (
+	"encoding/binary"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"city_bike/sim"
+
+	"github.com/gonutz/mixer/wav"
+)
+
+const (
+	effectVolume = 0.8
+	engineVolume = 0.4
+	musicVolume  = 0.5
+
+	// The engine loop is resampled to these pitches. The pitch follows the
+	// speed of the car.
+	minEnginePitch  = 1.0
+	maxEnginePitch  = 2.0
+	enginePitchStep = 0.1
+
+	// musicFade is how much the volume of a music track changes per frame when
+	// crossfading between tracks, so a crossfade takes one second.
+	musicFade = musicVolume / 60
+	// engineFade is how fast the engine fades between its pitches.
+	engineFade = engineVolume / 5
+
+	// loopOverlap is how long before the end of a loop the next repetition
+	// starts. The looped sounds fade in and out over this time at their edges.
+	loopOverlap = 40 * time.Millisecond
+)
+
+// Player plays the sound effects, the car engine and the music.
+type Player struct {
+	backend backend
+	sounds  map[string]source
+	// engine is the engine loop at its different pitches, from low to high.
+	engine []source
+	fader  fader
+}
+
+// New loads all WAV files in the root of fsys. It returns an error if there is
+// no sound output.
+func New(fsys fs.FS) (*Player, error) {
+	b, err := newBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		backend: b,
+		sounds:  make(map[string]source),
+		fader:   fader{loops: make(map[source]*loop)},
+	}
+
+	paths, err := fs.Glob(fsys, "*.wav")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		w, err := load(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(path, ".wav")
+		if name == sim.SoundEngine {
+			for pitch := minEnginePitch; pitch < maxEnginePitch+enginePitchStep/2; pitch += enginePitchStep {
+				s, err := b.newSource(changePitch(w, pitch))
+				if err != nil {
+					return nil, err
+				}
+				p.engine = append(p.engine, s)
+			}
+		} else {
+			p.sounds[name], err = b.newSource(w)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// load reads a WAV file and converts it to the format that the mixer plays.
+func load(fsys fs.FS, path string) (*wav.Wave, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w, err := wav.Read(f)
+	if err != nil {
+		return nil, err
+	}
+	return wav.ConvertTo44100Hz2Channels16BitSamples(w), nil
+}
+
+// SetVolume sets the master volume in the range [0..1].
+func (p *Player) SetVolume(v float64) {
+	p.backend.setVolume(float32(v))
+}
+
+// Update plays the audio of one frame. It must be called once per frame.
+func (p *Player) Update(a sim.Audio) {
+	for _, name := range a.Effects {
+		if s, ok := p.sounds[name]; ok {
+			s.play(effectVolume)
+		}
+	}
+
+	var engine source
+	if a.Engine > 0 && len(p.engine) > 0 {
+		pitch := 1 + 0.8*(a.Engine-1)
+		i := round((pitch - minEnginePitch) / enginePitchStep)
+		engine = p.engine[min(len(p.engine)-1, max(0, i))]
+	}
+	for _, s := range p.engine {
+		p.fader.update(s, s == engine, engineVolume, engineFade)
+	}
+
+	for _, name := range []string{sim.MusicMenu, sim.MusicGame} {
+		if s, ok := p.sounds[name]; ok {
+			p.fader.update(s, name == a.Music, musicVolume, musicFade)
+		}
+	}
+}
+
+// fader plays loops and fades them in and out.
+type fader struct {
+	loops map[source]*loop
+}
+
+// update fades the loop of s in if it is on, otherwise out. The volume changes
+// by speed per call.
+func (f *fader) update(s source, on bool, volume, speed float32) {
+	l := f.loops[s]
+	if l == nil {
+		if !on {
+			return
+		}
+		l = &loop{}
+		f.loops[s] = l
+	}
+
+	if on {
+		l.volume = min(volume, l.volume+speed)
+	} else {
+		l.volume = max(0, l.volume-speed)
+	}
+	l.update(s)
+	if l.volume == 0 {
+		delete(f.loops, s)
+	}
+}
+
+// loop plays a sound over and over. Each repetition starts before the last one
+// ended, see loopOverlap.
+type loop struct {
+	voices []voice
+	volume float32
+}
+
+func (l *loop) update(s source) {
+	l.voices = slices.DeleteFunc(l.voices, voice.Stopped)
+
+	if l.volume == 0 {
+		for _, v := range l.voices {
+			v.SetPosition(v.Length())
+		}
+		l.voices = nil
+		return
+	}
+
+	if len(l.voices) == 0 {
+		l.voices = append(l.voices, s.play(l.volume))
+	} else if last := l.voices[len(l.voices)-1]; last.Length()-last.Position() < loopOverlap {
+		l.voices = append(l.voices, s.play(l.volume))
+	}
+	for _, v := range l.voices {
+		v.SetVolume(l.volume)
+	}
+}
+
+// changePitch plays w faster by the given factor which raises its pitch. The
+// wave must be 16 bit stereo.
+func changePitch(w *wav.Wave, factor float64) *wav.Wave {
+	const frameSize = 4
+	inFrames := len(w.Data) / frameSize
+	outFrames := int(float64(inFrames) / factor)
+	data := make([]byte, outFrames*frameSize)
+
+	sample := func(frame, channel int) float64 {
+		frame = min(frame, inFrames-1)
+		return float64(int16(binary.LittleEndian.Uint16(w.Data[frame*frameSize+channel*2:])))
+	}
+
+	for i := range outFrames {
+		pos := float64(i) * factor
+		j := int(pos)
+		t := pos - float64(j)
+		for channel := range 2 {
+			v := (1-t)*sample(j, channel) + t*sample(j+1, channel)
+			binary.LittleEndian.PutUint16(data[i*frameSize+channel*2:], uint16(int16(v)))
+		}
+	}
+
+	return &wav.Wave{
+		ChannelCount:     w.ChannelCount,
+		SamplesPerSecond: w.SamplesPerSecond,
+		BitsPerSample:    w.BitsPerSample,
+		Data:             data,
+	}
+}
+
+func round(x float64) int {
+	if x < 0 {
+		return int(x - 0.5)
+	}
+	return int(x + 0.5)
+}
+
+// backend is the sound output.
+type backend interface {
+	newSource(w *wav.Wave) (source, error)
+	setVolume(v float32)
+}
+
+// source is a loaded sound which can be played multiple times at once.
+type source interface {
+	play(volume float32) voice
+}
+
+// voice is a single playing instance of a source.
+type voice interface {
+	SetVolume(float32)
+	SetPosition(time.Duration)
+	Position() time.Duration
+	Length() time.Duration
+	Stopped() bool
+}
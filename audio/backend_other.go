@@ -0,0 +1,9 @@
+//go:build !windows
+
+package audio
+
+import "errors"
+
+func newBackend() (backend, error) {
+	return nil, errors.New("sound is only supported on Windows")
+}
@@ -0,0 +1,42 @@
+package audio
+
+import (
+	"github.com/gonutz/mixer"
+	"github.com/gonutz/mixer/wav"
+)
+
+// mixerBackend plays the sounds through DirectSound.
+type mixerBackend struct{}
+
+func newBackend() (backend, error) {
+	// The audio is loaded before the window opens, so this initializes the
+	// mixer. The window's own call to Init then does nothing, but the window
+	// still closes the mixer when it is closed.
+	if err := mixer.Init(); err != nil {
+		return nil, err
+	}
+	return mixerBackend{}, nil
+}
+
+func (mixerBackend) newSource(w *wav.Wave) (source, error) {
+	s, err := mixer.NewSoundSource(w)
+	if err != nil {
+		return nil, err
+	}
+	return mixerSource{s}, nil
+}
+
+func (mixerBackend) setVolume(v float32) {
+	mixer.SetVolume(v)
+}
+
+type mixerSource struct {
+	mixer.SoundSource
+}
+
+func (s mixerSource) play(volume float32) voice {
+	v := s.PlayPaused()
+	v.SetVolume(volume)
+	v.SetPaused(false)
+	return v
+}
@@ -0,0 +1,220 @@
+// Command atlaspack packs the sprites of the game, the PNG files in the rsc
+// folder, into one image and writes it together with an index of where each
+// sprite is, atlas.png and atlas.json, into the same folder. See package atlas
+// for how they are read.
+//
+// Sprites that are always drawn in the same color are listed in tints.json in
+// the folder, keyed by the name of the tinted sprite:
+//
+//	{"car_0_parked": {"image": "car_0", "tint": [0.55, 0.65, 0.9]}}
+//
+// They are packed already tinted, since the game cannot tint a part of the
+// atlas without cutting it out.
+//
+// Run it from the repository root with
+//
+//	go run ./cmd/atlaspack
+//
+// whenever a sprite was added or changed.
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"city_bike/atlas"
+)
+
+var dir = flag.String("d", "rsc", "the folder with the sprites, the atlas is written to it as well")
+
+// skip are the images in the folder that are not sprites. The icon is set as
+// the window icon and the font has an atlas of its own.
+var skip = []string{"atlas.png", "icon.png", "font.png"}
+
+// padding is the number of empty pixels between frames. It keeps the edges of
+// neighboring frames from bleeding into each other when they are scaled.
+const padding = 1
+
+// tinted is an entry of tints.json, a copy of the sprite image with its
+// red, green and blue multiplied by tint.
+type tinted struct {
+	Image string     `json:"image"`
+	Tint  [3]float64 `json:"tint"`
+}
+
+type sprite struct {
+	name  string
+	image image.Image
+	x, y  int
+}
+
+func main() {
+	flag.Parse()
+
+	paths, err := filepath.Glob(filepath.Join(*dir, "*.png"))
+	if err != nil {
+		fail(err)
+	}
+	var sprites []*sprite
+	for _, path := range paths {
+		file := filepath.Base(path)
+		if slices.Contains(skip, file) {
+			continue
+		}
+		img, err := readPNG(path)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		sprites = append(sprites, &sprite{name: strings.TrimSuffix(file, ".png"), image: img})
+	}
+	if len(sprites) == 0 {
+		fail(fmt.Errorf("there are no sprites in %s", *dir))
+	}
+	tintedSprites, err := tintSprites(sprites)
+	if err != nil {
+		fail(err)
+	}
+	sprites = append(sprites, tintedSprites...)
+
+	// Tall sprites go first so that the rows are filled with sprites of
+	// similar heights. Sorting by name as well keeps the atlas the same
+	// every time the tool runs.
+	slices.SortFunc(sprites, func(a, b *sprite) int {
+		return cmp.Or(
+			cmp.Compare(b.image.Bounds().Dy(), a.image.Bounds().Dy()),
+			cmp.Compare(a.name, b.name),
+		)
+	})
+
+	// The atlas is the smallest power of two wide that fits the widest sprite
+	// and is not higher than it is wide.
+	w := 1
+	for _, s := range sprites {
+		for w < s.image.Bounds().Dx() {
+			w *= 2
+		}
+	}
+	h := pack(sprites, w)
+	for h > w {
+		w *= 2
+		h = pack(sprites, w)
+	}
+
+	index := atlas.Atlas{Image: "atlas", Frames: make(map[string]atlas.Frame)}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for _, s := range sprites {
+		b := s.image.Bounds()
+		draw.Draw(img, b.Sub(b.Min).Add(image.Pt(s.x, s.y)), s.image, b.Min, draw.Src)
+		index.Frames[s.name] = atlas.Frame{X: s.x, Y: s.y, W: b.Dx(), H: b.Dy()}
+	}
+
+	var imgFile bytes.Buffer
+	if err := png.Encode(&imgFile, img); err != nil {
+		fail(err)
+	}
+	var indexFile bytes.Buffer
+	enc := json.NewEncoder(&indexFile)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(index); err != nil {
+		fail(err)
+	}
+
+	for name, data := range map[string][]byte{"atlas.png": imgFile.Bytes(), "atlas.json": indexFile.Bytes()} {
+		if err := os.WriteFile(filepath.Join(*dir, name), data, 0666); err != nil {
+			fail(err)
+		}
+	}
+	fmt.Printf("packed %d sprites into a %dx%d atlas\n", len(sprites), w, h)
+}
+
+// pack places the sprites in rows from left to right in an atlas of width w
+// and returns the height of the atlas.
+func pack(sprites []*sprite, w int) int {
+	x, y, rowH := 0, 0, 0
+	for _, s := range sprites {
+		b := s.image.Bounds()
+		if x > 0 && x+b.Dx() > w {
+			x = 0
+			y += rowH + padding
+			rowH = 0
+		}
+		s.x, s.y = x, y
+		x += b.Dx() + padding
+		rowH = max(rowH, b.Dy())
+	}
+	return y + rowH
+}
+
+// tintSprites returns the tinted sprites of tints.json, if the file exists.
+func tintSprites(sprites []*sprite) ([]*sprite, error) {
+	path := filepath.Join(*dir, "tints.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tints map[string]tinted
+	if err := json.Unmarshal(data, &tints); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var result []*sprite
+	for name, t := range tints {
+		i := slices.IndexFunc(sprites, func(s *sprite) bool { return s.name == t.Image })
+		if i == -1 {
+			return nil, fmt.Errorf("%s: %s tints the missing sprite %s", path, name, t.Image)
+		}
+		if slices.ContainsFunc(sprites, func(s *sprite) bool { return s.name == name }) {
+			return nil, fmt.Errorf("%s: %s is a sprite of its own", path, name)
+		}
+		result = append(result, &sprite{name: name, image: tint(sprites[i].image, t.Tint)})
+	}
+	return result, nil
+}
+
+// tint multiplies the red, green and blue of the image by those of c, like
+// the draw library tints an image.
+func tint(img image.Image, c [3]float64) image.Image {
+	b := img.Bounds()
+	tinted := image.NewNRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			p := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			p.R = uint8(math.Round(float64(p.R) * c[0]))
+			p.G = uint8(math.Round(float64(p.G) * c[1]))
+			p.B = uint8(math.Round(float64(p.B) * c[2]))
+			tinted.SetNRGBA(x, y, p)
+		}
+	}
+	return tinted
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
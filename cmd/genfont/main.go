@@ -0,0 +1,236 @@
+// Command genfont draws the bitmap font of the game and writes its atlas and
+// metrics, font.png and font.json, into the rsc folder. See package font for
+// how they are read.
+//
+// Run it from the repository root with
+//
+//	go run ./cmd/genfont
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"city_bike/font"
+)
+
+var outDir = flag.String("o", "rsc", "the folder to write the font files to")
+
+const (
+	// lineHeight is the height of a line of text. Capitals are 6 pixels high,
+	// descenders reach 2 pixels further down.
+	lineHeight = 9
+	// atlasW is the width of the atlas, glyphs are packed into rows.
+	atlasW = 128
+	// spaceAdvance is the width of the space character.
+	spaceAdvance = 3
+)
+
+// glyphs are drawn with '#' for set pixels. Every glyph has 8 rows, the first
+// 6 are above the baseline. The width of a glyph is the length of its rows.
+var glyphs = map[rune][]string{
+	'A': {".##.", "#..#", "#..#", "####", "#..#", "#..#", "....", "...."},
+	'B': {"###.", "#..#", "###.", "#..#", "#..#", "###.", "....", "...."},
+	'C': {".###", "#...", "#...", "#...", "#...", ".###", "....", "...."},
+	'D': {"###.", "#..#", "#..#", "#..#", "#..#", "###.", "....", "...."},
+	'E': {"####", "#...", "###.", "#...", "#...", "####", "....", "...."},
+	'F': {"####", "#...", "###.", "#...", "#...", "#...", "....", "...."},
+	'G': {".###", "#...", "#...", "#.##", "#..#", ".###", "....", "...."},
+	'H': {"#..#", "#..#", "####", "#..#", "#..#", "#..#", "....", "...."},
+	'I': {"###", ".#.", ".#.", ".#.", ".#.", "###", "...", "..."},
+	'J': {"...#", "...#", "...#", "...#", "#..#", ".##.", "....", "...."},
+	'K': {"#..#", "#.#.", "##..", "#.#.", "#..#", "#..#", "....", "...."},
+	'L': {"#...", "#...", "#...", "#...", "#...", "####", "....", "...."},
+	'M': {"#...#", "##.##", "#.#.#", "#...#", "#...#", "#...#", ".....", "....."},
+	'N': {"#..#", "##.#", "#.##", "#..#", "#..#", "#..#", "....", "...."},
+	'O': {".##.", "#..#", "#..#", "#..#", "#..#", ".##.", "....", "...."},
+	'P': {"###.", "#..#", "#..#", "###.", "#...", "#...", "....", "...."},
+	'Q': {".##.", "#..#", "#..#", "#..#", "#.#.", ".#.#", "....", "...."},
+	'R': {"###.", "#..#", "#..#", "###.", "#.#.", "#..#", "....", "...."},
+	'S': {".###", "#...", ".##.", "...#", "...#", "###.", "....", "...."},
+	'T': {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", ".....", "....."},
+	'U': {"#..#", "#..#", "#..#", "#..#", "#..#", ".##.", "....", "...."},
+	'V': {"#...#", "#...#", "#...#", ".#.#.", ".#.#.", "..#..", ".....", "....."},
+	'W': {"#...#", "#...#", "#...#", "#.#.#", "##.##", "#...#", ".....", "....."},
+	'X': {"#..#", "#..#", ".##.", ".##.", "#..#", "#..#", "....", "...."},
+	'Y': {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", ".....", "....."},
+	'Z': {"####", "...#", "..#.", ".#..", "#...", "####", "....", "...."},
+
+	'a': {"....", ".##.", "...#", ".###", "#..#", ".###", "....", "...."},
+	'b': {"#...", "#...", "###.", "#..#", "#..#", "###.", "....", "...."},
+	'c': {"....", ".###", "#...", "#...", "#...", ".###", "....", "...."},
+	'd': {"...#", "...#", ".###", "#..#", "#..#", ".###", "....", "...."},
+	'e': {"....", ".##.", "#..#", "####", "#...", ".###", "....", "...."},
+	'f': {".##", "#..", "###", "#..", "#..", "#..", "...", "..."},
+	'g': {"....", ".###", "#..#", "#..#", "#..#", ".###", "...#", ".##."},
+	'h': {"#...", "#...", "###.", "#..#", "#..#", "#..#", "....", "...."},
+	'i': {"#", ".", "#", "#", "#", "#", ".", "."},
+	'j': {".#", "..", ".#", ".#", ".#", ".#", ".#", "#."},
+	'k': {"#...", "#..#", "#.#.", "##..", "#.#.", "#..#", "....", "...."},
+	'l': {"#", "#", "#", "#", "#", "#", ".", "."},
+	'm': {".....", "##.#.", "#.#.#", "#.#.#", "#.#.#", "#.#.#", ".....", "....."},
+	'n': {"....", "###.", "#..#", "#..#", "#..#", "#..#", "....", "...."},
+	'o': {"....", ".##.", "#..#", "#..#", "#..#", ".##.", "....", "...."},
+	'p': {"....", "###.", "#..#", "#..#", "#..#", "###.", "#...", "#..."},
+	'q': {"....", ".###", "#..#", "#..#", "#..#", ".###", "...#", "...#"},
+	'r': {"....", "#.##", "##..", "#...", "#...", "#...", "....", "...."},
+	's': {"...", "###", "#..", "###", "..#", "###", "...", "..."},
+	't': {".#.", "###", ".#.", ".#.", ".#.", "..#", "...", "..."},
+	'u': {"....", "#..#", "#..#", "#..#", "#..#", ".###", "....", "...."},
+	'v': {".....", "#...#", "#...#", ".#.#.", ".#.#.", "..#..", ".....", "....."},
+	'w': {".....", "#...#", "#...#", "#.#.#", "#.#.#", ".#.#.", ".....", "....."},
+	'x': {"....", "#..#", "#..#", ".##.", "#..#", "#..#", "....", "...."},
+	'y': {"....", "#..#", "#..#", "#..#", "#..#", ".###", "...#", ".##."},
+	'z': {"....", "####", "...#", ".##.", "#...", "####", "....", "...."},
+
+	// The digits are as wide as each other so that numbers do not jump
+	// around while they count.
+	'0': {".##.", "#..#", "#..#", "#..#", "#..#", ".##.", "....", "...."},
+	'1': {"...#", "..##", ".#.#", "#..#", "...#", "...#", "....", "...."},
+	'2': {".##.", "#..#", "...#", "..#.", ".#..", "####", "....", "...."},
+	'3': {".##.", "#..#", "..#.", "...#", "#..#", ".##.", "....", "...."},
+	'4': {"..#.", ".#..", "#...", "#.#.", "####", "..#.", "....", "...."},
+	'5': {"####", "#...", "###.", "...#", "#..#", ".##.", "....", "...."},
+	'6': {".###", "#...", "###.", "#..#", "#..#", ".##.", "....", "...."},
+	'7': {"####", "...#", "..#.", ".#..", ".#..", "#...", "....", "...."},
+	'8': {".##.", "#..#", ".##.", "#..#", "#..#", ".##.", "....", "...."},
+	'9': {".##.", "#..#", "#..#", ".###", "...#", "###.", "....", "...."},
+
+	'.':  {".", ".", ".", ".", ".", "#", ".", "."},
+	',':  {".", ".", ".", ".", ".", "#", "#", "."},
+	':':  {".", ".", "#", ".", ".", "#", ".", "."},
+	';':  {".", ".", "#", ".", ".", "#", "#", "."},
+	'!':  {"#", "#", "#", "#", ".", "#", ".", "."},
+	'?':  {".##.", "#..#", "..#.", ".#..", "....", ".#..", "....", "...."},
+	'\'': {"#", "#", ".", ".", ".", ".", ".", "."},
+	'"':  {"#.#", "#.#", "...", "...", "...", "...", "...", "..."},
+	'-':  {"...", "...", "...", "###", "...", "...", "...", "..."},
+	'+':  {"...", "...", ".#.", "###", ".#.", "...", "...", "..."},
+	'=':  {"...", "...", "###", "...", "###", "...", "...", "..."},
+	'*':  {"...", "#.#", ".#.", "#.#", "...", "...", "...", "..."},
+	'/':  {"..#", "..#", ".#.", ".#.", "#..", "#..", "...", "..."},
+	'(':  {".#", "#.", "#.", "#.", "#.", ".#", "..", ".."},
+	')':  {"#.", ".#", ".#", ".#", ".#", "#.", "..", ".."},
+	'[':  {"##", "#.", "#.", "#.", "#.", "##", "..", ".."},
+	']':  {"##", ".#", ".#", ".#", ".#", "##", "..", ".."},
+	'<':  {"...", "..#", ".#.", "#..", ".#.", "..#", "...", "..."},
+	'>':  {"...", "#..", ".#.", "..#", ".#.", "#..", "...", "..."},
+	'_':  {"....", "....", "....", "....", "....", "....", "####", "...."},
+	'%':  {"#..#", "...#", "..#.", ".#..", "#...", "#..#", "....", "...."},
+	'#':  {".....", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".....", "....."},
+}
+
+// kerning moves the glyphs after overhanging capitals closer, and those
+// after letters that leave a gap at the baseline.
+func kerning() map[string]int {
+	k := make(map[string]int)
+	for _, a := range "TVY" {
+		for _, b := range "acegmnopqrsuvwxyz.," {
+			k[string(a)+string(b)] = -1
+		}
+	}
+	for _, pair := range []string{"LT", "LV", "LY", "F.", "F,", "P.", "P,", "r.", "r,"} {
+		k[pair] = -1
+	}
+	return k
+}
+
+func main() {
+	flag.Parse()
+
+	chars := make([]rune, 0, len(glyphs))
+	for r := range glyphs {
+		chars = append(chars, r)
+	}
+	slices.Sort(chars)
+
+	f := font.Font{
+		Atlas:      "font",
+		LineHeight: lineHeight,
+		Fallback:   "?",
+		Glyphs:     map[string]font.Glyph{" ": {Advance: spaceAdvance}},
+		Kerning:    kerning(),
+	}
+
+	// Pack the glyphs into rows, cut to the rows that have pixels.
+	type placed struct {
+		rows []string
+		x, y int
+	}
+	var packed []placed
+	x, y := 0, 0
+	for _, r := range chars {
+		rows := glyphs[r]
+		if len(rows) != 8 {
+			fail(fmt.Errorf("glyph %q has %d rows", r, len(rows)))
+		}
+		w := len(rows[0])
+		top, bottom := len(rows), 0
+		for i, row := range rows {
+			if len(row) != w {
+				fail(fmt.Errorf("glyph %q has rows of different widths", r))
+			}
+			if strings.Contains(row, "#") {
+				top = min(top, i)
+				bottom = i + 1
+			}
+		}
+		if x+w > atlasW {
+			x = 0
+			y += len(rows)
+		}
+		packed = append(packed, placed{rows[top:bottom], x, y})
+		f.Glyphs[string(r)] = font.Glyph{
+			X:       x,
+			Y:       y,
+			W:       w,
+			H:       bottom - top,
+			DY:      top,
+			Advance: w + 1,
+		}
+		x += w + 1
+	}
+
+	atlas := image.NewNRGBA(image.Rect(0, 0, atlasW, y+8))
+	for _, p := range packed {
+		for dy, row := range p.rows {
+			for dx, c := range row {
+				if c == '#' {
+					atlas.Set(p.x+dx, p.y+dy, color.White)
+				}
+			}
+		}
+	}
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, atlas); err != nil {
+		fail(err)
+	}
+	var metrics bytes.Buffer
+	enc := json.NewEncoder(&metrics)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(f); err != nil {
+		fail(err)
+	}
+
+	for name, data := range map[string][]byte{"font.png": img.Bytes(), "font.json": metrics.Bytes()} {
+		if err := os.WriteFile(filepath.Join(*outDir, name), data, 0666); err != nil {
+			fail(err)
+		}
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,254 @@
+// Command gensounds synthesizes the sound effects and music of the game and
+// writes them as WAV files into the rsc folder.
+//
+// Run it from the repository root with
+//
+//	go run ./cmd/gensounds
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const sampleRate = 22050
+
+// fadeTime is how long looped sounds fade in and out at their edges. The game
+// starts the next repetition of a loop while the last one is fading out.
+const fadeTime = 0.04
+
+var outDir = flag.String("o", "rsc", "the folder to write the WAV files to")
+
+// random is seeded so that running the tool again yields the same files.
+var random = rand.New(rand.NewSource(1))
+
+func main() {
+	flag.Parse()
+
+	sounds := []struct {
+		name    string
+		samples []float64
+	}{
+		{"pedal", pedal()},
+		{"crash", crash()},
+		{"menu_move", blips(0.04, 880)},
+		{"menu_select", blips(0.06, 660, 990)},
+		{"jump", sweep(0.15, 300, 900)},
+		{"bump", sweep(0.1, 160, 60)},
+		{"pickup", blips(0.05, 990, 1320)},
+		{"engine", engine()},
+		{"music_menu", menuMusic()},
+		{"music_game", gameMusic()},
+	}
+	for _, s := range sounds {
+		path := filepath.Join(*outDir, s.name+".wav")
+		if err := os.WriteFile(path, encodeWAV(s.samples), 0666); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// encodeWAV writes the samples, which are in the range [-1..1], as 8 bit mono
+// PCM data.
+func encodeWAV(samples []float64) []byte {
+	var b bytes.Buffer
+	write := func(data ...any) {
+		for _, d := range data {
+			binary.Write(&b, binary.LittleEndian, d)
+		}
+	}
+	// Chunks must have an even size, a padding byte follows odd data.
+	padding := len(samples) % 2
+	b.WriteString("RIFF")
+	write(uint32(36 + len(samples) + padding))
+	b.WriteString("WAVE")
+	b.WriteString("fmt ")
+	write(
+		uint32(16),         // chunk size
+		uint16(1),          // PCM
+		uint16(1),          // channels
+		uint32(sampleRate), // samples per second
+		uint32(sampleRate), // bytes per second
+		uint16(1),          // block align
+		uint16(8),          // bits per sample
+	)
+	b.WriteString("data")
+	write(uint32(len(samples)))
+	for _, s := range samples {
+		s = max(-1, min(1, s))
+		b.WriteByte(byte(128 + math.Round(s*127)))
+	}
+	if padding != 0 {
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+func samples(seconds float64) []float64 {
+	return make([]float64, int(seconds*sampleRate))
+}
+
+func square(phase float64) float64 {
+	if phase-math.Floor(phase) < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func triangle(phase float64) float64 {
+	p := phase - math.Floor(phase)
+	return 4*math.Abs(p-0.5) - 1
+}
+
+func saw(phase float64) float64 {
+	return 2*(phase-math.Floor(phase)) - 1
+}
+
+func pedal() []float64 {
+	s := samples(0.03)
+	last := 0.0
+	for i := range s {
+		t := float64(i) / sampleRate
+		noise := random.Float64()*2 - 1
+		// The difference of two noise values removes the low frequencies,
+		// which makes it a click instead of a thump.
+		s[i] = 0.5 * (noise - last) * math.Exp(-t*200)
+		last = noise
+	}
+	return s
+}
+
+func crash() []float64 {
+	s := samples(0.8)
+	phase := 0.0
+	for i := range s {
+		t := float64(i) / sampleRate
+		noise := random.Float64()*2 - 1
+		phase += (120 - 80*t) / sampleRate
+		thump := math.Sin(2*math.Pi*phase) * math.Exp(-t*6)
+		s[i] = 0.6*noise*math.Exp(-t*5) + 0.6*thump
+	}
+	return s
+}
+
+func blips(seconds float64, frequencies ...float64) []float64 {
+	var s []float64
+	for _, f := range frequencies {
+		blip := samples(seconds)
+		for i := range blip {
+			t := float64(i) / sampleRate
+			blip[i] = 0.25 * square(f*t) * (1 - t/seconds)
+		}
+		s = append(s, blip...)
+	}
+	return s
+}
+
+// sweep slides the frequency of a square wave from one value to another.
+func sweep(seconds, from, to float64) []float64 {
+	s := samples(seconds)
+	phase := 0.0
+	for i := range s {
+		t := float64(i) / float64(len(s))
+		phase += (from + (to-from)*t) / sampleRate
+		s[i] = 0.25 * square(phase) * (1 - t)
+	}
+	return s
+}
+
+// engine is a loop of the car's engine at its lowest speed. The game plays it
+// faster for higher speeds.
+func engine() []float64 {
+	s := samples(1)
+	for i := range s {
+		t := float64(i) / sampleRate
+		rumble := 0.6*saw(55*t) + 0.3*square(110*t)
+		pulse := 0.7 + 0.3*math.Sin(2*math.Pi*25*t)
+		s[i] = 0.35 * rumble * pulse
+	}
+	fadeEdges(s)
+	return s
+}
+
+// fadeEdges fades the samples in at the start and out at the end.
+func fadeEdges(s []float64) {
+	n := int(fadeTime * sampleRate)
+	for i := range n {
+		f := float64(i) / float64(n)
+		s[i] *= f
+		s[len(s)-1-i] *= f
+	}
+}
+
+// note is a tone in a melody. 0 is a pause, 1 is A2 (110 Hz) and every step
+// above that is a semitone.
+type note int
+
+func (n note) frequency() float64 {
+	return 110 * math.Pow(2, float64(n-1)/12)
+}
+
+// song plays a melody with a square wave over a bass line with a triangle
+// wave. The melody has one note per beat, the bass notes are spread evenly over
+// the same time.
+func song(bpm float64, melody, bass []note) []float64 {
+	beat := 60 / bpm
+	s := samples(beat * float64(len(melody)))
+	add := func(notes []note, volume float64, wave func(float64) float64) {
+		perNote := len(s) / len(notes)
+		for i, n := range notes {
+			if n == 0 {
+				continue
+			}
+			f := n.frequency()
+			for j := range perNote {
+				t := float64(j) / sampleRate
+				envelope := math.Exp(-t * 3 / beat)
+				s[i*perNote+j] += volume * envelope * wave(f*t)
+			}
+		}
+	}
+	add(melody, 0.15, square)
+	add(bass, 0.3, triangle)
+	fadeEdges(s)
+	return s
+}
+
+func menuMusic() []float64 {
+	melody := []note{
+		25, 0, 29, 32, 30, 29, 27, 0,
+		25, 0, 29, 32, 34, 32, 30, 29,
+		22, 0, 25, 29, 27, 25, 24, 0,
+		22, 24, 25, 27, 29, 27, 25, 0,
+	}
+	bass := []note{
+		1, 8, 1, 8,
+		6, 13, 6, 13,
+		11, 6, 11, 6,
+		8, 3, 8, 3,
+	}
+	return song(100, melody, bass)
+}
+
+func gameMusic() []float64 {
+	melody := []note{
+		25, 25, 32, 25, 30, 25, 29, 27,
+		25, 25, 32, 25, 34, 32, 30, 32,
+		23, 23, 30, 23, 29, 23, 27, 25,
+		23, 25, 27, 29, 30, 32, 34, 37,
+	}
+	bass := []note{
+		1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 6, 6, 8, 8,
+		11, 11, 11, 11, 11, 11, 11, 11,
+		11, 11, 8, 8, 6, 6, 8, 8,
+	}
+	return song(150, melody, bass)
+}
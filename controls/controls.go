@@ -0,0 +1,119 @@
+// Package controls maps the keys of the keyboard to the actions of the game.
+// Keys are identified by their names, e.g. "Left", "A" or "Space", so the
+// bindings can be stored in the settings file and shown to the player.
+package controls
+
+import "strings"
+
+// Action is something that the player can do in the game.
+type Action int
+
+const (
+	PedalLeft Action = iota
+	PedalRight
+	Jump
+	LaneUp
+	LaneDown
+	Pause
+	Confirm
+	Back
+	Up
+	Down
+	Left
+	Right
+
+	// NOTE ActionCount has to come last
+	ActionCount
+)
+
+var actionIDs = [ActionCount]string{
+	PedalLeft:  "pedal_left",
+	PedalRight: "pedal_right",
+	Jump:       "jump",
+	LaneUp:     "lane_up",
+	LaneDown:   "lane_down",
+	Pause:      "pause",
+	Confirm:    "confirm",
+	Back:       "back",
+	Up:         "up",
+	Down:       "down",
+	Left:       "left",
+	Right:      "right",
+}
+
+// ID is the name of the action in the settings file.
+func (a Action) ID() string {
+	return actionIDs[a]
+}
+
+// String is the name of the action as shown to the player.
+func (a Action) String() string {
+	return strings.ToUpper(strings.ReplaceAll(a.ID(), "_", " "))
+}
+
+// Gameplay reports whether the action is used while riding, as opposed to
+// navigating the menus. A key may only be bound to one action in each group.
+func (a Action) Gameplay() bool {
+	switch a {
+	case PedalLeft, PedalRight, Jump, LaneUp, LaneDown, Pause:
+		return true
+	}
+	return false
+}
+
+// Bindings maps action IDs to the names of the keys that trigger the action.
+type Bindings map[string][]string
+
+// DefaultBindings are the keys that are used if the player did not change
+// them.
+func DefaultBindings() Bindings {
+	return Bindings{
+		PedalLeft.ID():  {"Left", "A"},
+		PedalRight.ID(): {"Right", "D"},
+		Jump.ID():       {"Space"},
+		LaneUp.ID():     {"Up", "W"},
+		LaneDown.ID():   {"Down", "S"},
+		Pause.ID():      {"Escape", "P"},
+		Confirm.ID():    {"Space", "Enter", "NumEnter"},
+		Back.ID():       {"Escape"},
+		Up.ID():         {"Up", "W"},
+		Down.ID():       {"Down", "S"},
+		Left.ID():       {"Left", "A"},
+		Right.ID():      {"Right", "D"},
+	}
+}
+
+// Keys returns the names of the keys bound to the action.
+func (b Bindings) Keys(a Action) []string {
+	return b[a.ID()]
+}
+
+// Bind makes key the only key for the action. The key is removed from the other
+// actions in the same group so that one key does not trigger two actions at
+// once.
+func (b Bindings) Bind(a Action, key string) {
+	for other := range ActionCount {
+		if other != a && other.Gameplay() == a.Gameplay() {
+			keys := b.Keys(other)
+			var kept []string
+			for _, k := range keys {
+				if k != key {
+					kept = append(kept, k)
+				}
+			}
+			b[other.ID()] = kept
+		}
+	}
+	b[a.ID()] = []string{key}
+}
+
+// FillMissing sets the default keys for all actions that are not in b. Actions
+// that are in b but have no keys stay unbound.
+func (b Bindings) FillMissing() {
+	def := DefaultBindings()
+	for a := range ActionCount {
+		if _, ok := b[a.ID()]; !ok {
+			b[a.ID()] = def.Keys(a)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package controls
+
+import (
+	"slices"
+	"testing"
+)
+
+// scriptedGamepad returns a gamepad that reports the given states, one per
+// frame. It is disconnected after the last state.
+func scriptedGamepad(states ...GamepadState) *Gamepad {
+	return NewGamepad(func() (GamepadState, bool) {
+		if len(states) == 0 {
+			return GamepadState{}, false
+		}
+		s := states[0]
+		states = states[1:]
+		return s, true
+	})
+}
+
+func TestGamepadReportsPressesOnce(t *testing.T) {
+	held := GamepadState{Buttons: ButtonA, RightTrigger: 1}
+	pad := scriptedGamepad(GamepadState{}, held, held, GamepadState{}, held)
+	want := []bool{false, true, false, false, true}
+
+	for frame, w := range want {
+		pad.Update()
+		if got := pad.WasPressed(Jump); got != w {
+			t.Errorf("frame %d: Jump pressed is %v, want %v", frame, got, w)
+		}
+		if got := pad.WasPressed(PedalRight); got != w {
+			t.Errorf("frame %d: PedalRight pressed is %v, want %v", frame, got, w)
+		}
+	}
+
+	pad.Update()
+	if pad.WasPressed(Jump) {
+		t.Error("a disconnected gamepad reports a press")
+	}
+}
+
+func TestGamepadAnalogThresholds(t *testing.T) {
+	tests := []struct {
+		state GamepadState
+		want  Action
+	}{
+		{GamepadState{LeftTrigger: triggerThreshold + 0.1}, PedalLeft},
+		{GamepadState{RightTrigger: triggerThreshold + 0.1}, PedalRight},
+		{GamepadState{LeftY: -stickThreshold - 0.1}, LaneUp},
+		{GamepadState{LeftY: stickThreshold + 0.1}, LaneDown},
+		{GamepadState{LeftX: -stickThreshold - 0.1}, Left},
+		{GamepadState{LeftX: stickThreshold + 0.1}, Right},
+	}
+	for _, tt := range tests {
+		pad := scriptedGamepad(tt.state)
+		pad.Update()
+		if !pad.WasPressed(tt.want) {
+			t.Errorf("%+v does not press %v", tt.state, tt.want)
+		}
+	}
+
+	pad := scriptedGamepad(GamepadState{LeftTrigger: triggerThreshold - 0.1, LeftY: stickThreshold - 0.1})
+	pad.Update()
+	for a := range ActionCount {
+		if pad.WasPressed(a) {
+			t.Errorf("%v is pressed below its threshold", a)
+		}
+	}
+}
+
+func TestDevicesCombinePresses(t *testing.T) {
+	var fake Fake
+	pad := scriptedGamepad(GamepadState{}, GamepadState{Buttons: ButtonB})
+	devices := Devices{&fake, pad}
+
+	fake.Press(Confirm)
+	devices.Update()
+	if !devices.WasPressed(Confirm) || devices.WasPressed(Back) {
+		t.Error("want only Confirm pressed by the fake device")
+	}
+
+	fake.Press()
+	devices.Update()
+	if devices.WasPressed(Confirm) || !devices.WasPressed(Back) {
+		t.Error("want only Back pressed by the gamepad")
+	}
+}
+
+func TestBindRemovesKeyFromSameGroup(t *testing.T) {
+	b := DefaultBindings()
+	b.Bind(Jump, "A")
+
+	if keys := b.Keys(Jump); !slices.Equal(keys, []string{"A"}) {
+		t.Errorf("Jump has keys %v, want [A]", keys)
+	}
+	if keys := b.Keys(PedalLeft); slices.Contains(keys, "A") {
+		t.Errorf("PedalLeft still has A: %v", keys)
+	}
+	// The menus are a different group, A still moves left in them.
+	if keys := b.Keys(Left); !slices.Contains(keys, "A") {
+		t.Errorf("Left lost A: %v", keys)
+	}
+}
+
+func TestFillMissingKeepsUnboundActions(t *testing.T) {
+	b := Bindings{Jump.ID(): nil}
+	b.FillMissing()
+
+	if keys := b.Keys(Jump); len(keys) != 0 {
+		t.Errorf("unbound Jump got keys %v", keys)
+	}
+	if keys := b.Keys(Pause); !slices.Equal(keys, DefaultBindings().Keys(Pause)) {
+		t.Errorf("missing Pause got keys %v instead of the defaults", keys)
+	}
+}
@@ -0,0 +1,50 @@
+package controls
+
+// Device is a source of player input, e.g. the keyboard or a gamepad.
+type Device interface {
+	// Update is called once per frame, before WasPressed is used.
+	Update()
+	// WasPressed reports whether the action was triggered during the last
+	// frame.
+	WasPressed(a Action) bool
+}
+
+// Devices combines several devices into one. An action was pressed if it was
+// pressed on any of the devices.
+type Devices []Device
+
+func (d Devices) Update() {
+	for _, device := range d {
+		device.Update()
+	}
+}
+
+func (d Devices) WasPressed(a Action) bool {
+	for _, device := range d {
+		if device.WasPressed(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fake is a Device whose actions are set in code. It is meant for tests and
+// for driving the game without a window.
+type Fake struct {
+	pressed [ActionCount]bool
+}
+
+// Press makes WasPressed report exactly the given actions as pressed, until
+// the next call to Press.
+func (f *Fake) Press(actions ...Action) {
+	f.pressed = [ActionCount]bool{}
+	for _, a := range actions {
+		f.pressed[a] = true
+	}
+}
+
+func (f *Fake) Update() {}
+
+func (f *Fake) WasPressed(a Action) bool {
+	return f.pressed[a]
+}
@@ -0,0 +1,88 @@
+package controls
+
+// GamepadState is the state of a gamepad's buttons and axes at one moment.
+type GamepadState struct {
+	Buttons GamepadButtons
+	// LeftTrigger and RightTrigger go from 0 when released to 1 when fully
+	// pressed.
+	LeftTrigger  float64
+	RightTrigger float64
+	// LeftX and LeftY are the left stick's position, from -1 to 1. Positive Y
+	// is down.
+	LeftX float64
+	LeftY float64
+}
+
+// GamepadButtons is a bit set of the buttons that are held down.
+type GamepadButtons uint16
+
+const (
+	ButtonA GamepadButtons = 1 << iota
+	ButtonB
+	ButtonX
+	ButtonY
+	ButtonLeftBumper
+	ButtonRightBumper
+	ButtonBack
+	ButtonStart
+	ButtonDpadUp
+	ButtonDpadDown
+	ButtonDpadLeft
+	ButtonDpadRight
+)
+
+// Gamepad is a Device that maps a gamepad to actions. The player pedals with
+// either the triggers or the bumpers, left and right alternating just like on
+// the keyboard, jumps with A and changes lanes with the D-pad or the left
+// stick. The menus are navigated with the D-pad or the left stick, A confirms,
+// B goes back and Start pauses.
+//
+// The gamepad hardware is read through the poll function, which is called once
+// per frame and returns false if there is no gamepad.
+type Gamepad struct {
+	poll func() (GamepadState, bool)
+	last [ActionCount]bool
+	now  [ActionCount]bool
+}
+
+func NewGamepad(poll func() (GamepadState, bool)) *Gamepad {
+	return &Gamepad{poll: poll}
+}
+
+// These thresholds decide when an analog trigger or stick counts as pressed.
+const (
+	triggerThreshold = 0.5
+	stickThreshold   = 0.6
+)
+
+func (g *Gamepad) Update() {
+	g.last = g.now
+	g.now = [ActionCount]bool{}
+
+	s, ok := g.poll()
+	if !ok {
+		return
+	}
+
+	held := func(b GamepadButtons) bool {
+		return s.Buttons&b != 0
+	}
+	g.now[PedalLeft] = held(ButtonLeftBumper) || s.LeftTrigger > triggerThreshold
+	g.now[PedalRight] = held(ButtonRightBumper) || s.RightTrigger > triggerThreshold
+	g.now[Jump] = held(ButtonA)
+	g.now[LaneUp] = held(ButtonDpadUp) || s.LeftY < -stickThreshold
+	g.now[LaneDown] = held(ButtonDpadDown) || s.LeftY > stickThreshold
+	g.now[Pause] = held(ButtonStart)
+	g.now[Confirm] = held(ButtonA)
+	g.now[Back] = held(ButtonB)
+	g.now[Up] = held(ButtonDpadUp) || s.LeftY < -stickThreshold
+	g.now[Down] = held(ButtonDpadDown) || s.LeftY > stickThreshold
+	g.now[Left] = held(ButtonDpadLeft) || s.LeftX < -stickThreshold
+	g.now[Right] = held(ButtonDpadRight) || s.LeftX > stickThreshold
+}
+
+// WasPressed reports whether the action went from released to held in the last
+// frame.
+func (g *Gamepad) WasPressed(a Action) bool {
+	return g.now[a] && !g.last[a]
+}
@@ -0,0 +1,180 @@
+// Package font reads bitmap fonts in the pixel style of the game. A font is an
+// image with all of its glyphs, the atlas, and a JSON file with the metrics
+// that say where each glyph is in the atlas and how far to move on after it.
+// Both are generated by cmd/genfont.
+package font
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"unicode/utf8"
+)
+
+// Font is a bitmap font, read with Load or Parse. It lays out lines of text
+// with Line and names the images that its glyphs are drawn with, see
+// GlyphImage.
+type Font struct {
+	// Atlas is the name of the atlas image, without the ".png" extension.
+	Atlas string `json:"atlas"`
+	// LineHeight is the distance between the tops of two lines of text.
+	LineHeight int `json:"line_height"`
+	// Fallback is the character that is drawn for characters that are not
+	// in the font.
+	Fallback string `json:"fallback"`
+	// Glyphs are keyed by their character.
+	Glyphs map[string]Glyph `json:"glyphs"`
+	// Kerning changes the advance between the two characters of its keys.
+	Kerning map[string]int `json:"kerning"`
+
+	glyphs   map[rune]Glyph
+	kerning  map[[2]rune]int
+	fallback rune
+}
+
+// Glyph is where a character is in the atlas. All sizes are in pixels of the
+// font.
+type Glyph struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+	// DY is how far below the top of the line the glyph starts.
+	DY int `json:"dy"`
+	// Advance is how far the next character is to the right of this one.
+	Advance int `json:"advance"`
+}
+
+// Load reads the metrics of the font with the given name from name+".json" in
+// fsys.
+func Load(fsys fs.FS, name string) (*Font, error) {
+	data, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes the metrics of a font.
+func Parse(data []byte) (*Font, error) {
+	var f Font
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	f.glyphs = make(map[rune]Glyph, len(f.Glyphs))
+	for s, g := range f.Glyphs {
+		r, ok := single(s)
+		if !ok {
+			return nil, fmt.Errorf("font: glyph %q is not a single character", s)
+		}
+		f.glyphs[r] = g
+	}
+
+	f.kerning = make(map[[2]rune]int, len(f.Kerning))
+	for s, k := range f.Kerning {
+		a, size := utf8.DecodeRuneInString(s)
+		b, ok := single(s[size:])
+		if !ok {
+			return nil, fmt.Errorf("font: kerning %q is not a pair of characters", s)
+		}
+		f.kerning[[2]rune{a, b}] = k
+	}
+
+	var ok bool
+	f.fallback, ok = single(f.Fallback)
+	if _, found := f.glyphs[f.fallback]; !ok || !found {
+		return nil, fmt.Errorf("font: fallback %q is not a glyph", f.Fallback)
+	}
+
+	return &f, nil
+}
+
+func single(s string) (rune, bool) {
+	r, size := utf8.DecodeRuneInString(s)
+	return r, r != utf8.RuneError && size == len(s)
+}
+
+// Glyph returns the glyph of r, or the fallback glyph if the font does not
+// have r. It returns the character whose glyph it is.
+func (f *Font) Glyph(r rune) (rune, Glyph) {
+	if g, ok := f.glyphs[r]; ok {
+		return r, g
+	}
+	return f.fallback, f.glyphs[f.fallback]
+}
+
+// Kern returns the change of the advance from a to b.
+func (f *Font) Kern(a, b rune) int {
+	return f.kerning[[2]rune{a, b}]
+}
+
+// Placed is a glyph at its position in a line of text, relative to the
+// top-left corner of the line.
+type Placed struct {
+	Char rune
+	Glyph
+	X int
+}
+
+// Line lays out a line of text. It returns the glyphs and the width of the
+// line, which does not include the space after the last glyph.
+func (f *Font) Line(text string) ([]Placed, int) {
+	var placed []Placed
+	x, width := 0, 0
+	prev := rune(-1)
+	for _, r := range text {
+		r, g := f.Glyph(r)
+		if prev != -1 {
+			x += f.Kern(prev, r)
+		}
+		placed = append(placed, Placed{Char: r, Glyph: g, X: x})
+		width = max(width, x+g.W)
+		x += g.Advance
+		prev = r
+	}
+	return placed, width
+}
+
+// GlyphImage is the name of the image of the glyph of r in GlyphImages.
+func (f *Font) GlyphImage(r rune) string {
+	return fmt.Sprintf("%s_%d", f.Atlas, r)
+}
+
+// GlyphImages cuts the glyphs out of the atlas in fsys. It returns them as PNG
+// files, keyed by GlyphImage with the ".png" extension, so they can be drawn
+// like any other image. Glyphs without pixels, like the space, have no image.
+func (f *Font) GlyphImages(fsys fs.FS) (map[string][]byte, error) {
+	file, err := fsys.Open(f.Atlas + ".png")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	atlas, err := png.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	sub, ok := atlas.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("font: cannot cut glyphs out of %s.png", f.Atlas)
+	}
+
+	images := make(map[string][]byte)
+	for r, g := range f.glyphs {
+		if g.W == 0 || g.H == 0 {
+			continue
+		}
+		var buf bytes.Buffer
+		glyph := sub.SubImage(image.Rect(g.X, g.Y, g.X+g.W, g.Y+g.H))
+		if err := png.Encode(&buf, glyph); err != nil {
+			return nil, err
+		}
+		images[f.GlyphImage(r)+".png"] = buf.Bytes()
+	}
+	return images, nil
+}
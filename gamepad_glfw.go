@@ -0,0 +1,54 @@
+//go:build (glfw || !windows) && !js
+
+package main
+
+import (
+	"city_bike/controls"
+
+	"github.com/gonutz/glfw/v3.3/glfw"
+)
+
+// pollGamepad reads the first joystick that GLFW knows a gamepad mapping for.
+// The draw package has already initialized GLFW when this is called.
+func pollGamepad() (controls.GamepadState, bool) {
+	for joy := glfw.Joystick1; joy <= glfw.JoystickLast; joy++ {
+		if !joy.IsGamepad() {
+			continue
+		}
+		gs := joy.GetGamepadState()
+		if gs == nil {
+			continue
+		}
+
+		var s controls.GamepadState
+		buttons := []struct {
+			glfw     glfw.GamepadButton
+			controls controls.GamepadButtons
+		}{
+			{glfw.ButtonA, controls.ButtonA},
+			{glfw.ButtonB, controls.ButtonB},
+			{glfw.ButtonX, controls.ButtonX},
+			{glfw.ButtonY, controls.ButtonY},
+			{glfw.ButtonLeftBumper, controls.ButtonLeftBumper},
+			{glfw.ButtonRightBumper, controls.ButtonRightBumper},
+			{glfw.ButtonBack, controls.ButtonBack},
+			{glfw.ButtonStart, controls.ButtonStart},
+			{glfw.ButtonDpadUp, controls.ButtonDpadUp},
+			{glfw.ButtonDpadDown, controls.ButtonDpadDown},
+			{glfw.ButtonDpadLeft, controls.ButtonDpadLeft},
+			{glfw.ButtonDpadRight, controls.ButtonDpadRight},
+		}
+		for _, b := range buttons {
+			if gs.Buttons[b.glfw] == glfw.Press {
+				s.Buttons |= b.controls
+			}
+		}
+		// GLFW reports the triggers from -1 (released) to 1 (pressed).
+		s.LeftTrigger = float64(gs.Axes[glfw.AxisLeftTrigger]+1) / 2
+		s.RightTrigger = float64(gs.Axes[glfw.AxisRightTrigger]+1) / 2
+		s.LeftX = float64(gs.Axes[glfw.AxisLeftX])
+		s.LeftY = float64(gs.Axes[glfw.AxisLeftY])
+		return s, true
+	}
+	return controls.GamepadState{}, false
+}
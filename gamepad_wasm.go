@@ -0,0 +1,10 @@
+//go:build js
+
+package main
+
+import "city_bike/controls"
+
+// pollGamepad does not support gamepads in the browser yet.
+func pollGamepad() (controls.GamepadState, bool) {
+	return controls.GamepadState{}, false
+}
@@ -0,0 +1,80 @@
+//go:build !glfw && !js && windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+
+	"city_bike/controls"
+)
+
+// On Windows the draw package uses Direct3D instead of GLFW, so gamepads are
+// read through XInput.
+
+var xInputGetState = func() *syscall.LazyProc {
+	for _, dll := range []string{"xinput1_4.dll", "xinput9_1_0.dll"} {
+		proc := syscall.NewLazyDLL(dll).NewProc("XInputGetState")
+		if proc.Find() == nil {
+			return proc
+		}
+	}
+	return nil
+}()
+
+type xInputState struct {
+	packetNumber uint32
+	buttons      uint16
+	leftTrigger  uint8
+	rightTrigger uint8
+	thumbLX      int16
+	thumbLY      int16
+	thumbRX      int16
+	thumbRY      int16
+}
+
+// pollGamepad reads the first connected XInput controller.
+func pollGamepad() (controls.GamepadState, bool) {
+	if xInputGetState == nil {
+		return controls.GamepadState{}, false
+	}
+
+	for user := uintptr(0); user < 4; user++ {
+		var state xInputState
+		ret, _, _ := xInputGetState.Call(user, uintptr(unsafe.Pointer(&state)))
+		if ret != 0 {
+			continue
+		}
+
+		var s controls.GamepadState
+		buttons := []struct {
+			xinput   uint16
+			controls controls.GamepadButtons
+		}{
+			{0x1000, controls.ButtonA},
+			{0x2000, controls.ButtonB},
+			{0x4000, controls.ButtonX},
+			{0x8000, controls.ButtonY},
+			{0x0100, controls.ButtonLeftBumper},
+			{0x0200, controls.ButtonRightBumper},
+			{0x0020, controls.ButtonBack},
+			{0x0010, controls.ButtonStart},
+			{0x0001, controls.ButtonDpadUp},
+			{0x0002, controls.ButtonDpadDown},
+			{0x0004, controls.ButtonDpadLeft},
+			{0x0008, controls.ButtonDpadRight},
+		}
+		for _, b := range buttons {
+			if state.buttons&b.xinput != 0 {
+				s.Buttons |= b.controls
+			}
+		}
+		s.LeftTrigger = float64(state.leftTrigger) / 255
+		s.RightTrigger = float64(state.rightTrigger) / 255
+		// XInput's Y axis points up.
+		s.LeftX = float64(state.thumbLX) / 32767
+		s.LeftY = -float64(state.thumbLY) / 32767
+		return s, true
+	}
+	return controls.GamepadState{}, false
+}
@@ -4,6 +4,8 @@ go 1.24.5
 
 require (
 	github.com/gonutz/ease v1.0.0
+	github.com/gonutz/glfw v1.0.2
+	github.com/gonutz/mixer v1.0.0
 	github.com/gonutz/prototype v1.9.2
 )
 
@@ -11,7 +13,5 @@ require (
 	github.com/gonutz/d3d9 v1.2.4 // indirect
 	github.com/gonutz/ds v1.0.0 // indirect
 	github.com/gonutz/gl v1.0.0 // indirect
-	github.com/gonutz/glfw v1.0.2 // indirect
-	github.com/gonutz/mixer v1.0.0 // indirect
 	github.com/gonutz/w32/v2 v2.2.0 // indirect
 )
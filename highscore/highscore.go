@@ -0,0 +1,150 @@
+// Package highscore keeps the table of the best runs, stored as JSON in the
+// user's config directory.
+package highscore
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"city_bike/tuning"
+)
+
+// MaxEntries is the number of entries that the table keeps per difficulty
+// level.
+const MaxEntries = 10
+
+// MaxNameLength is the number of characters that a player name may have.
+const MaxNameLength = 12
+
+// Table holds the best runs of all difficulty levels, sorted from best to
+// worst. Runs are only ranked against runs of the same difficulty.
+type Table struct {
+	Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+	Name  string  `json:"name"`
+	Miles float64 `json:"miles"`
+	// Difficulty is the name of the difficulty level that the run was played
+	// at.
+	Difficulty string `json:"difficulty"`
+}
+
+// Ranking returns the entries of the given difficulty level, from best to
+// worst.
+func (t *Table) Ranking(difficulty string) []Entry {
+	var ranking []Entry
+	for _, e := range t.Entries {
+		if e.Difficulty == difficulty {
+			ranking = append(ranking, e)
+		}
+	}
+	return ranking
+}
+
+// Qualifies reports whether a run of the given distance and difficulty would
+// make it into the table.
+func (t *Table) Qualifies(difficulty string, miles float64) bool {
+	if miles <= 0 {
+		return false
+	}
+	ranking := t.Ranking(difficulty)
+	return len(ranking) < MaxEntries || miles > ranking[len(ranking)-1].Miles
+}
+
+// Add inserts the entry at its place in the table and returns its rank among
+// the entries of its difficulty, or -1 if it does not make it into the table.
+// On equal distances the older entry stays ahead.
+func (t *Table) Add(e Entry) int {
+	if !t.Qualifies(e.Difficulty, e.Miles) {
+		return -1
+	}
+	i := sort.Search(len(t.Entries), func(i int) bool {
+		return t.Entries[i].Miles < e.Miles
+	})
+	t.Entries = append(t.Entries, Entry{})
+	copy(t.Entries[i+1:], t.Entries[i:])
+	t.Entries[i] = e
+	rank := t.rank(i)
+	t.trim()
+	return rank
+}
+
+// rank returns the rank of the i'th entry among the entries of its difficulty.
+func (t *Table) rank(i int) int {
+	rank := 0
+	for _, e := range t.Entries[:i] {
+		if e.Difficulty == t.Entries[i].Difficulty {
+			rank++
+		}
+	}
+	return rank
+}
+
+// trim removes the entries that are not among the best MaxEntries of their
+// difficulty.
+func (t *Table) trim() {
+	counts := make(map[string]int)
+	kept := t.Entries[:0]
+	for _, e := range t.Entries {
+		counts[e.Difficulty]++
+		if counts[e.Difficulty] <= MaxEntries {
+			kept = append(kept, e)
+		}
+	}
+	t.Entries = kept
+}
+
+// DefaultPath is the file that the table is stored in, inside the user's
+// config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "city_bike", "highscores.json"), nil
+}
+
+// Load reads the table from the given file. A missing file is not an error,
+// it results in an empty table.
+func Load(path string) (*Table, error) {
+	var t Table
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	// Entries from before there were difficulty levels were played at what
+	// is now the default.
+	for i := range t.Entries {
+		if t.Entries[i].Difficulty == "" {
+			t.Entries[i].Difficulty = tuning.DefaultDifficulty
+		}
+	}
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		return t.Entries[i].Miles > t.Entries[j].Miles
+	})
+	t.trim()
+	return &t, nil
+}
+
+// Save writes the table to the given file, creating its directory if needed.
+func (t *Table) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
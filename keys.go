@@ -0,0 +1,49 @@
+package main
+
+import (
+	"city_bike/controls"
+
+	"github.com/gonutz/prototype/draw"
+)
+
+// keysByName maps the names returned by draw.Key.String back to their keys.
+// These names are used for the key bindings in the settings file.
+var keysByName = func() map[string]draw.Key {
+	m := make(map[string]draw.Key)
+	for k := draw.KeyA; k <= draw.KeyPause; k++ {
+		m[k.String()] = k
+	}
+	return m
+}()
+
+// keyboard is the controls.Device for the window's keyboard, using the key
+// bindings from the settings.
+type keyboard struct {
+	window   draw.Window
+	bindings controls.Bindings
+}
+
+func (k *keyboard) Update() {}
+
+// WasPressed reports whether any of the keys bound to the action was pressed
+// in the last frame. Unknown key names are ignored.
+func (k *keyboard) WasPressed(a controls.Action) bool {
+	for _, name := range k.bindings.Keys(a) {
+		if key, ok := keysByName[name]; ok && k.window.WasKeyPressed(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// keysPressed returns the names of all keys that were pressed in the last
+// frame.
+func (g *game) keysPressed() []string {
+	var names []string
+	for k := draw.KeyA; k <= draw.KeyPause; k++ {
+		if g.window.WasKeyPressed(k) {
+			names = append(names, k.String())
+		}
+	}
+	return names
+}
@@ -0,0 +1,246 @@
+// Package replay stores the riding input of a run so that the run can be
+// reproduced exactly later on. Since the game is deterministic, the input of
+// every frame together with the window size is all it takes.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Recording is the input of a run, starting at the first frame after the menu.
+// Miles and CaughtAt are the outcome of the run which a replay can be verified
+// against.
+type Recording struct {
+	WindowW int
+	WindowH int
+	// FitHeight is the setting of the same name, which decides how much of
+	// the street is seen in a window of the recorded size.
+	FitHeight bool
+	// PixelScale is the setting of the same name, which decides the size of
+	// the street in the window together with FitHeight.
+	PixelScale int
+	// Seed is the seed of the city that the run took place in.
+	Seed int64
+	// Difficulty is the name of the difficulty level of the run.
+	Difficulty string
+	Frames     []Frame
+	Miles      float64
+	// CaughtAt is the index into Frames of the frame in which the bike
+	// crashed, either because the car caught it or because it hit an
+	// obstacle. It is -1 if the bike never crashed.
+	CaughtAt int
+}
+
+// Frame is a bit set of the keys that were pressed in a single frame.
+type Frame uint8
+
+const (
+	PedalLeft Frame = 1 << iota
+	PedalRight
+	Jump
+	LaneUp
+	LaneDown
+)
+
+// New starts an empty recording for the given window size, scaling, city seed
+// and difficulty level.
+func New(windowW, windowH int, fitHeight bool, pixelScale int, seed int64, difficulty string) *Recording {
+	return &Recording{
+		WindowW:    windowW,
+		WindowH:    windowH,
+		FitHeight:  fitHeight,
+		PixelScale: pixelScale,
+		Seed:       seed,
+		Difficulty: difficulty,
+		CaughtAt:   -1,
+	}
+}
+
+// Has reports whether all keys in k are set in f.
+func (f Frame) Has(k Frame) bool {
+	return f&k == k
+}
+
+// Verify returns an error if the given outcome of a replay does not match the
+// recorded outcome.
+func (r *Recording) Verify(miles float64, caughtAt int) error {
+	if miles != r.Miles {
+		return fmt.Errorf(
+			"replay mismatch: recorded %.6f miles but replay reached %.6f",
+			r.Miles, miles,
+		)
+	}
+	if caughtAt != r.CaughtAt {
+		return fmt.Errorf(
+			"replay mismatch: recorded catch at frame %d but replay caught at frame %d",
+			r.CaughtAt, caughtAt,
+		)
+	}
+	return nil
+}
+
+const magic = "CBRP"
+
+const version = 8
+
+// MaxFrames is the longest recording that Read accepts, four hours at 60
+// frames per second. The number of frames is read from the file, a corrupt
+// file must not make Read allocate more than that.
+const MaxFrames = 4 * 60 * 60 * 60
+
+// Write encodes the recording. Consecutive equal frames are run-length
+// encoded, which keeps files small since most frames have no key presses.
+func (r *Recording) Write(w io.Writer) error {
+	buf := []byte(magic)
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(r.WindowW))
+	buf = binary.AppendUvarint(buf, uint64(r.WindowH))
+	fitHeight := byte(0)
+	if r.FitHeight {
+		fitHeight = 1
+	}
+	buf = append(buf, fitHeight)
+	buf = binary.AppendUvarint(buf, uint64(r.PixelScale))
+	buf = binary.AppendVarint(buf, r.Seed)
+	buf = binary.AppendUvarint(buf, uint64(len(r.Difficulty)))
+	buf = append(buf, r.Difficulty...)
+	buf = binary.LittleEndian.AppendUint64(buf, math.Float64bits(r.Miles))
+	buf = binary.AppendVarint(buf, int64(r.CaughtAt))
+	buf = binary.AppendUvarint(buf, uint64(len(r.Frames)))
+	for i := 0; i < len(r.Frames); {
+		n := 1
+		for i+n < len(r.Frames) && r.Frames[i+n] == r.Frames[i] {
+			n++
+		}
+		buf = binary.AppendUvarint(buf, uint64(n))
+		buf = append(buf, byte(r.Frames[i]))
+		i += n
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// Read decodes a recording that was encoded with Write.
+func Read(r io.Reader) (*Recording, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("replay: not a City Bike recording")
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("replay: unsupported version %d", header[len(magic)])
+	}
+
+	var rec Recording
+	w, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	h, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	rec.WindowW, rec.WindowH = int(w), int(h)
+	fitHeight, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if fitHeight > 1 {
+		return nil, errors.New("replay: corrupt scaling")
+	}
+	rec.FitHeight = fitHeight == 1
+	pixelScale, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if pixelScale > 255 {
+		return nil, errors.New("replay: corrupt scaling")
+	}
+	rec.PixelScale = int(pixelScale)
+	rec.Seed, err = binary.ReadVarint(br)
+	if err != nil {
+		return nil, err
+	}
+	difficultyLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if difficultyLen > 64 {
+		return nil, errors.New("replay: corrupt difficulty")
+	}
+	difficulty := make([]byte, difficultyLen)
+	if _, err := io.ReadFull(br, difficulty); err != nil {
+		return nil, err
+	}
+	rec.Difficulty = string(difficulty)
+	var miles [8]byte
+	if _, err := io.ReadFull(br, miles[:]); err != nil {
+		return nil, err
+	}
+	rec.Miles = math.Float64frombits(binary.LittleEndian.Uint64(miles[:]))
+	caughtAt, err := binary.ReadVarint(br)
+	if err != nil {
+		return nil, err
+	}
+	rec.CaughtAt = int(caughtAt)
+	frameCount, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if frameCount > MaxFrames {
+		return nil, fmt.Errorf("replay: %d frames are more than the maximum of %d", frameCount, MaxFrames)
+	}
+	rec.Frames = make([]Frame, 0, frameCount)
+
+	for uint64(len(rec.Frames)) < frameCount {
+		n, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		f, err := br.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 || uint64(len(rec.Frames))+n > frameCount {
+			return nil, errors.New("replay: corrupt frame data")
+		}
+		for range n {
+			rec.Frames = append(rec.Frames, Frame(f))
+		}
+	}
+
+	return &rec, nil
+}
+
+// Save writes the recording to the file at path.
+func (r *Recording) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = r.Write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Load reads a recording from the file at path.
+func Load(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
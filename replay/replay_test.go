@@ -0,0 +1,44 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	rec := New(1280, 720, true, 4, -42, "hard")
+	rec.Frames = []Frame{0, 0, 0, PedalLeft, 0, PedalRight | Jump, LaneDown, LaneDown}
+	rec.Miles = 1.25
+	rec.CaughtAt = 7
+
+	var buf bytes.Buffer
+	if err := rec.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rec) {
+		t.Errorf("read %+v, want %+v", got, rec)
+	}
+}
+
+func TestReadRejectsHugeFrameCount(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New(1280, 720, false, 0, 1, "normal").Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// Replace the frame count of 0 at the end with one that does not fit
+	// into memory, followed by a single run of that many frames.
+	data := buf.Bytes()[:buf.Len()-1]
+	data = binary.AppendUvarint(data, 1<<60)
+	data = binary.AppendUvarint(data, 1<<60)
+	data = append(data, byte(PedalLeft))
+
+	if _, err := Read(bytes.NewReader(data)); err == nil {
+		t.Error("a recording of 2^60 frames was read")
+	}
+}
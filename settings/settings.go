@@ -0,0 +1,122 @@
+// Package settings holds the player's preferences, stored as JSON in the
+// user's config directory. They are loaded before the window opens.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"city_bike/controls"
+	"city_bike/tuning"
+)
+
+type Settings struct {
+	Fullscreen bool `json:"fullscreen"`
+	// WindowW and WindowH are the size of the window when not in fullscreen.
+	// They take effect the next time the game is started.
+	WindowW int `json:"window_width"`
+	WindowH int `json:"window_height"`
+	// FitHeight makes the game as wide as the window instead of showing black
+	// bars next to it. Wider windows then show more of the street.
+	FitHeight bool `json:"fit_height"`
+	// PixelScale is the largest size of a pixel of the art in window pixels.
+	// Smaller pixels leave more room around the game, or show more of the
+	// street with FitHeight. 0 makes the pixels as large as the window
+	// allows.
+	PixelScale int `json:"pixel_scale"`
+	// Volume is the master volume, from 0 (mute) to 1 (full volume).
+	Volume float64 `json:"volume"`
+	// DailyCity makes all runs of a day take place in the same city, so that
+	// players can compete with each other.
+	DailyCity bool `json:"daily_city"`
+	// Difficulty is the name of the difficulty level, one of
+	// tuning.Difficulties.
+	Difficulty string `json:"difficulty"`
+	// Keys maps game actions to the names of the keys that trigger them.
+	Keys controls.Bindings `json:"keys"`
+}
+
+// MaxPixelScale is the largest PixelScale that can be chosen.
+const MaxPixelScale = 16
+
+// WindowSizes are the window sizes that can be chosen in the settings screen.
+var WindowSizes = [][2]int{
+	{1280, 720},
+	{1500, 800},
+	{1600, 900},
+	{1920, 1080},
+	{2560, 1440},
+}
+
+// Default returns the settings that are used when there is no settings file.
+func Default() *Settings {
+	return &Settings{
+		Fullscreen: true,
+		WindowW:    1500,
+		WindowH:    800,
+		Volume:     1,
+		Difficulty: tuning.DefaultDifficulty,
+		Keys:       controls.DefaultBindings(),
+	}
+}
+
+// DefaultPath is the file that the settings are stored in, inside the user's
+// config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "city_bike", "settings.json"), nil
+}
+
+// Load reads the settings from the given file. Values that are missing in the
+// file keep their defaults, a missing file results in the default settings.
+func Load(path string) (*Settings, error) {
+	s := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, err
+	}
+	s.clamp()
+	return s, nil
+}
+
+func (s *Settings) clamp() {
+	def := Default()
+	if s.WindowW <= 0 || s.WindowH <= 0 {
+		s.WindowW, s.WindowH = def.WindowW, def.WindowH
+	}
+	s.PixelScale = min(MaxPixelScale, max(0, s.PixelScale))
+	s.Volume = min(1, max(0, s.Volume))
+	if !slices.Contains(tuning.Difficulties, s.Difficulty) {
+		s.Difficulty = def.Difficulty
+	}
+	if s.Keys == nil {
+		s.Keys = def.Keys
+	}
+	s.Keys.FillMissing()
+}
+
+// Save writes the settings to the given file, creating its directory if
+// needed.
+func (s *Settings) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
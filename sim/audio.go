@@ -0,0 +1,50 @@
+package sim
+
+// Sounds are named after their WAV files in rsc, without the ".wav" extension.
+const (
+	SoundPedal      = "pedal"
+	SoundCrash      = "crash"
+	SoundJump       = "jump"
+	SoundBump       = "bump"
+	SoundPickup     = "pickup"
+	SoundMenuMove   = "menu_move"
+	SoundMenuSelect = "menu_select"
+	// SoundEngine is the car's engine. It is looped and its pitch follows the
+	// speed of the car.
+	SoundEngine = "engine"
+
+	MusicMenu = "music_menu"
+	MusicGame = "music_game"
+)
+
+// Audio is what should be heard in a frame, see Game.Audio.
+type Audio struct {
+	// Effects are the sounds that start in this frame.
+	Effects []string
+	// Music is the track that should be playing, it is empty for silence.
+	Music string
+	// Engine is the speed of the car for the engine loop, it is 0 if the
+	// engine cannot be heard.
+	Engine float64
+}
+
+// Audio returns what should be heard in the frame of the last call to Step.
+func (g *Game) Audio() Audio {
+	return g.audio
+}
+
+func (g *Game) play(sound string) {
+	g.audio.Effects = append(g.audio.Effects, sound)
+}
+
+func (g *Game) music() string {
+	state := g.State
+	if state == ShowingSettings || state == ShowingControls {
+		state = g.settingsReturn
+	}
+	if state == FadingInMenu || state == FadingOutMenu ||
+		state == ShowingHighScores {
+		return MusicMenu
+	}
+	return MusicGame
+}
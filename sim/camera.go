@@ -0,0 +1,108 @@
+package sim
+
+import "math"
+
+// playZoom is the zoom while playing, every pixel of the art is a pixel of the
+// canvas.
+const playZoom = 1
+
+// camera is the view of the world in a run. World positions are in pixels of
+// the art with y going up from the bottom of the street, the camera turns them
+// into canvas pixels with y going down.
+type camera struct {
+	// x and y are the world position of the bottom-left corner of the view.
+	x, y float64
+	// zoom is the number of canvas pixels per world pixel.
+	zoom float64
+	// screenW and screenH are the size of the canvas.
+	screenW, screenH int
+	// speedY is how fast the view moves down during the intro.
+	speedY float64
+	// shake is how far, in world pixels, the view is shaken. It dies down
+	// over time and does not move the camera itself, only what is drawn.
+	shake      float64
+	shakeFrame int
+}
+
+// update lets the shake die down. It is called once per frame.
+func (c *camera) update(shakeDecay float64) {
+	c.shake *= shakeDecay
+	if c.shake < 0.1 {
+		c.shake = 0
+	}
+	c.shakeFrame++
+}
+
+// shakeBy shakes the view at least as far as the given strength.
+func (c *camera) shakeBy(strength float64) {
+	c.shake = max(c.shake, strength)
+}
+
+// shakeOffset is how far the view is moved by the shake in this frame. It
+// depends only on the frame so replays shake the same way.
+func (c *camera) shakeOffset() (float64, float64) {
+	if c.shake == 0 {
+		return 0, 0
+	}
+	f := float64(c.shakeFrame)
+	return c.shake * math.Sin(f*1.9), c.shake * math.Sin(f*2.7+1)
+}
+
+// size returns the width and height of the view in world pixels.
+func (c *camera) size() (float64, float64) {
+	return float64(c.screenW) / c.zoom, float64(c.screenH) / c.zoom
+}
+
+// toScreen returns the screen position of a world position.
+func (c *camera) toScreen(x, y float64) (float64, float64) {
+	dx, dy := c.shakeOffset()
+	return (x - c.x + dx) * c.zoom,
+		float64(c.screenH) - (y-c.y+dy)*c.zoom
+}
+
+// toWorld returns the world position of a screen position, it is the inverse
+// of toScreen.
+func (c *camera) toWorld(screenX, screenY float64) (float64, float64) {
+	dx, dy := c.shakeOffset()
+	return c.x - dx + screenX/c.zoom,
+		c.y - dy + (float64(c.screenH)-screenY)/c.zoom
+}
+
+// clamp keeps the view from going left of the start of the street or below
+// it.
+func (c *camera) clamp() {
+	c.x = max(0, c.x)
+	c.y = max(0, c.y)
+}
+
+// zoomAt changes the zoom and keeps the world position that is at the given
+// screen position where it is on the screen.
+func (c *camera) zoomAt(zoom, screenX, screenY float64) {
+	beforeX, beforeY := c.toWorld(screenX, screenY)
+	c.zoom = zoom
+	afterX, afterY := c.toWorld(screenX, screenY)
+	c.x += beforeX - afterX
+	c.y += beforeY - afterY
+}
+
+// follow moves the middle of the view part of the way towards x.
+func (c *camera) follow(x, part float64) {
+	w, _ := c.size()
+	c.x = blend(c.x, x-w/2, part)
+}
+
+// descend moves the view down from the sky to the street, fast at first and
+// slowing down near the street. It reports whether the view arrived.
+func (c *camera) descend() bool {
+	if c.y > 150 {
+		c.speedY -= 0.02
+	} else {
+		c.speedY = min(-0.1, c.speedY+0.02)
+	}
+	c.y += c.speedY
+	if c.y < 0 {
+		c.y = 0
+		return true
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package sim
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether two positions are the same up to rounding errors.
+func near(x1, y1, x2, y2 float64) bool {
+	return math.Abs(x1-x2) < 1e-9 && math.Abs(y1-y2) < 1e-9
+}
+
+func TestCameraRoundTrip(t *testing.T) {
+	points := [][2]float64{{0, 0}, {123.5, 45.25}, {-10, 300}, {5000, -20}}
+	for _, zoom := range []float64{0.3, 1, 2.5} {
+		for _, shake := range []float64{0, 3} {
+			c := camera{x: 812.5, y: 40, zoom: zoom, screenW: 480, screenH: 270}
+			c.shakeBy(shake)
+			c.update(1)
+			for _, p := range points {
+				sx, sy := c.toScreen(p[0], p[1])
+				x, y := c.toWorld(sx, sy)
+				if !near(x, y, p[0], p[1]) {
+					t.Errorf("zoom %v, shake %v: %v went to the screen at %v, %v and back to %v, %v",
+						zoom, shake, p, sx, sy, x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestCameraZoomKeepsPivot(t *testing.T) {
+	for _, pivot := range [][2]float64{{240, 270}, {0, 0}, {100, 50}} {
+		for _, shake := range []float64{0, 3} {
+			c := camera{x: 300, y: 120, zoom: 0.3, screenW: 480, screenH: 270}
+			c.shakeBy(shake)
+			c.update(1)
+			wx, wy := c.toWorld(pivot[0], pivot[1])
+
+			c.zoomAt(2, pivot[0], pivot[1])
+
+			if sx, sy := c.toScreen(wx, wy); !near(sx, sy, pivot[0], pivot[1]) {
+				t.Errorf("shake %v: the world position %v, %v moved from the pivot %v to %v, %v",
+					shake, wx, wy, pivot, sx, sy)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package sim
+
+// The game is laid out on a canvas of a fixed size in pixels of the art, so
+// that everybody sees the same part of the street whatever the size of their
+// window. The canvas is scaled up to the window by a whole number so that all
+// pixels of the art are equally large.
+const (
+	canvasW = 192
+	canvasH = 108
+)
+
+// canvas is where the canvas is in the window.
+type canvas struct {
+	// x and y are the window position of the top-left corner of the canvas.
+	x, y int
+	// w and h are the size of the canvas in canvas pixels.
+	w, h int
+	// scale is the number of window pixels per canvas pixel.
+	scale int
+}
+
+// newCanvas fits the canvas into the window, with black bars around it. With
+// fitHeight, the canvas gets as wide as the window instead, so wider windows
+// see more of the street. maxScale limits the scale unless it is 0.
+func newCanvas(windowW, windowH int, fitHeight bool, maxScale int) canvas {
+	c := canvas{w: canvasW, h: canvasH}
+	if fitHeight {
+		c.scale = windowH / canvasH
+	} else {
+		c.scale = min(windowW/canvasW, windowH/canvasH)
+	}
+	if maxScale > 0 {
+		c.scale = min(c.scale, maxScale)
+	}
+	c.scale = max(1, c.scale)
+	if fitHeight {
+		c.w = (windowW + c.scale - 1) / c.scale
+	}
+	c.x = (windowW - c.w*c.scale) / 2
+	c.y = (windowH - c.h*c.scale) / 2
+	return c
+}
+
+// toWindow returns the window position of a canvas position.
+func (c canvas) toWindow(x, y any) (float64, float64) {
+	s := float64(c.scale)
+	return float64(c.x) + toFloat64(x)*s, float64(c.y) + toFloat64(y)*s
+}
+
+// fromWindow returns the canvas pixel that the window position is in.
+func (c canvas) fromWindow(x, y int) (int, int) {
+	return floorDiv(x-c.x, c.scale), floorDiv(y-c.y, c.scale)
+}
+
+// windowRect returns the window pixels of a rectangle on the canvas. Its
+// edges are rounded so that rectangles which touch on the canvas also touch in
+// the window.
+func (c canvas) windowRect(x, y, w, h any) (int, int, int, int) {
+	left, top := c.toWindow(x, y)
+	right, bottom := c.toWindow(toFloat64(x)+toFloat64(w), toFloat64(y)+toFloat64(h))
+	return round(left), round(top), round(right) - round(left), round(bottom) - round(top)
+}
+
+// pixel is the size of a window pixel in canvas pixels, for details that are
+// finer than the art.
+func (c canvas) pixel() float64 {
+	return 1 / float64(c.scale)
+}
+
+// drawBars covers the window outside of the canvas so nothing that reaches
+// over its edges is seen.
+func (g *Game) drawBars(windowW, windowH int) {
+	c := g.canvas
+	right := c.x + c.w*c.scale
+	bottom := c.y + c.h*c.scale
+	bars := [][4]int{
+		{0, 0, windowW, c.y},
+		{0, bottom, windowW, windowH - bottom},
+		{0, c.y, c.x, bottom - c.y},
+		{right, c.y, windowW - right, bottom - c.y},
+	}
+	for _, b := range bars {
+		if b[2] > 0 && b[3] > 0 {
+			g.commands = append(g.commands, Command{
+				Kind:  FillRect,
+				X:     float64(b[0]),
+				Y:     float64(b[1]),
+				W:     b[2],
+				H:     b[3],
+				Color: RGB(0, 0, 0),
+			})
+		}
+	}
+}
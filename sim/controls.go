@@ -0,0 +1,76 @@
+package sim
+
+import (
+	"fmt"
+	"strings"
+
+	"city_bike/controls"
+)
+
+func (g *Game) openControls() {
+	g.State = ShowingControls
+	g.controlsMenu = menuList{}
+	g.rebinding = false
+}
+
+func (g *Game) showControls() {
+	if g.settingsReturn == Paused {
+		g.commands = append(g.commands, g.frozen...)
+		g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, 0.6))
+	} else {
+		g.rect(0, 0, g.canvas.w, g.canvas.h, rgb(12, 19, 34))
+	}
+
+	keys := g.settings().Keys
+	resetItem := int(controls.ActionCount)
+	backItem := resetItem + 1
+
+	var items []string
+	for a := range controls.ActionCount {
+		bound := strings.Join(keys.Keys(a), ", ")
+		if g.rebinding && a == controls.Action(g.controlsMenu.selection) {
+			bound = "..."
+		}
+		items = append(items, fmt.Sprintf("%s\t%s", a, bound))
+	}
+	items = append(items, "RESET DEFAULTS", "BACK")
+
+	textScale := g.fontScale(0.4)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	y := float64(g.canvas.h)/2 - float64(len(items)+4)*lineH*3/4
+	g.centerText("CONTROLS", y, g.fontScale(0.6), rgb(255, 255, 200))
+	y += 3 * lineH
+
+	if g.rebinding {
+		// Show the menu without letting it react to the keys.
+		in := g.in
+		g.in = Input{MouseX: in.MouseX, MouseY: in.MouseY}
+		g.updateMenuList(&g.controlsMenu, items, y, textScale)
+		g.in = in
+
+		hint := "PRESS A KEY, CLICK TO CANCEL"
+		g.centerText(hint, y+float64(len(items)+1)*lineH*3/2, textScale, RGB(0.5, 0.5, 0.5))
+
+		if g.in.Clicked {
+			g.rebinding = false
+		} else if len(g.in.KeysPressed) > 0 {
+			action := controls.Action(g.controlsMenu.selection)
+			keys.Bind(action, g.in.KeysPressed[0])
+			g.rebinding = false
+			g.saveSettings()
+		}
+		return
+	}
+
+	activated := g.updateMenuList(&g.controlsMenu, items, y, textScale)
+	if g.in.Back || activated == backItem {
+		g.State = ShowingSettings
+		return
+	}
+	if activated == resetItem {
+		g.settings().Keys = controls.DefaultBindings()
+		g.saveSettings()
+	} else if activated != -1 {
+		g.rebinding = true
+	}
+}
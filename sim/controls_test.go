@@ -0,0 +1,30 @@
+package sim
+
+import (
+	"testing"
+
+	"city_bike/controls"
+)
+
+func TestClickRebindsClickedAction(t *testing.T) {
+	assets := loadTestAssets(t)
+	rebound := map[controls.Action]bool{}
+	for y := range canvasH {
+		g := New(assets)
+		g.Step(Input{WindowW: testWindowW, WindowH: testWindowH})
+		g.openSettings()
+		g.openControls()
+
+		clickAt(g, &g.controlsMenu, g.canvas.w/2, y)
+		g.showControls()
+
+		if g.rebinding {
+			rebound[controls.Action(g.controlsMenu.selection)] = true
+		}
+	}
+	for a := range controls.ActionCount {
+		if !rebound[a] {
+			t.Errorf("no click rebinds %v", a)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sim
+
+import "math"
+
+// The time of day follows the distance along the street. A run starts at
+// night and a full day passes every tuning.DayLength miles.
+
+// daytime is what the light looks like at a time of day.
+type daytime struct {
+	// skyTop and skyBottom are the colors of the sky gradient.
+	skyTop    Color
+	skyBottom Color
+	// light is the factor on the tints of the buildings.
+	light float32
+	// stars and lampGlow are the opacity of the stars and the lamps' light.
+	stars    float32
+	lampGlow float32
+}
+
+// daytimes are night, dawn, day and dusk, evenly spread over a day.
+var daytimes = []daytime{
+	{rgb(12, 19, 34), rgb(36, 34, 48), 1, 1, 1},
+	{rgb(58, 66, 116), rgb(222, 140, 112), 1.25, 0.3, 0.5},
+	{rgb(88, 146, 214), rgb(172, 208, 236), 1.6, 0, 0},
+	{rgb(66, 48, 106), rgb(214, 108, 82), 1.25, 0.3, 0.5},
+}
+
+// daytime returns the light at the world position x.
+func (g *Game) daytime(x float64) daytime {
+	length := g.tuning().DayLength / g.tuning().MilesPerPixel
+	t := math.Mod(max(0, x)/length, 1) * float64(len(daytimes))
+	i := int(t)
+	a, b := daytimes[i], daytimes[(i+1)%len(daytimes)]
+	f := float32(t - float64(i))
+	return daytime{
+		skyTop:    mixColors(a.skyTop, b.skyTop, f),
+		skyBottom: mixColors(a.skyBottom, b.skyBottom, f),
+		light:     a.light + (b.light-a.light)*f,
+		stars:     a.stars + (b.stars-a.stars)*f,
+		lampGlow:  a.lampGlow + (b.lampGlow-a.lampGlow)*f,
+	}
+}
+
+// lit returns the tint c in the given light.
+func (d daytime) lit(c Color) Color {
+	return RGBA(
+		min(1, c.R*d.light),
+		min(1, c.G*d.light),
+		min(1, c.B*d.light),
+		c.A,
+	)
+}
+
+func mixColors(a, b Color, f float32) Color {
+	return RGBA(
+		a.R+(b.R-a.R)*f,
+		a.G+(b.G-a.G)*f,
+		a.B+(b.B-a.B)*f,
+		a.A+(b.A-a.A)*f,
+	)
+}
@@ -0,0 +1,20 @@
+package sim
+
+import (
+	"slices"
+	"strings"
+
+	"city_bike/tuning"
+)
+
+// nextDifficulty returns the difficulty level dir steps away from the given
+// one, wrapping around at the ends.
+func nextDifficulty(difficulty string, dir int) string {
+	n := len(tuning.Difficulties)
+	i := max(0, slices.Index(tuning.Difficulties, difficulty))
+	return tuning.Difficulties[(i+dir+n)%n]
+}
+
+func difficultyText(difficulty string) string {
+	return strings.ToUpper(difficulty)
+}
@@ -0,0 +1,86 @@
+package sim
+
+import (
+	"math"
+	"strings"
+)
+
+// align is where a text is relative to the x position that it is drawn at.
+type align int
+
+const (
+	alignLeft align = iota
+	alignCenter
+	alignRight
+)
+
+// textStyle is how bitmapText draws a text.
+type textStyle struct {
+	// scale is the size of a pixel of the font in canvas pixels.
+	scale float64
+	color Color
+	// outline is drawn around the glyphs, one pixel of the font wide, unless
+	// it is transparent.
+	outline Color
+	align   align
+}
+
+// outlineOffsets are the directions in which the outline sticks out of a
+// glyph.
+var outlineOffsets = [8][2]float64{
+	{-1, -1}, {0, -1}, {1, -1},
+	{-1, 0}, {1, 0},
+	{-1, 1}, {0, 1}, {1, 1},
+}
+
+// bitmapText draws a text in the pixel font of the game, in canvas
+// coordinates. Depending on the alignment, x is the left edge, the middle or
+// the right edge of the text. y is the top of its first line. Lines are
+// separated by '\n'.
+func (g *Game) bitmapText(text string, x, y float64, style textStyle) {
+	f := g.assets.Font()
+	s := style.scale
+	for i, line := range strings.Split(text, "\n") {
+		glyphs, w := f.Line(line)
+		// The alignments are the halves of the width that are left of x. The
+		// text starts on a canvas pixel so its pixels line up with the art.
+		left := math.Round(x - float64(w)*s*float64(style.align)/2)
+		top := y + float64(i*f.LineHeight)*s
+
+		if style.outline.A > 0 {
+			for _, o := range outlineOffsets {
+				for _, p := range glyphs {
+					if p.W > 0 {
+						g.image(f.GlyphImage(p.Char), left+(float64(p.X)+o[0])*s, top+(float64(p.DY)+o[1])*s, s, style.outline)
+					}
+				}
+			}
+		}
+		for _, p := range glyphs {
+			if p.W > 0 {
+				g.image(f.GlyphImage(p.Char), left+float64(p.X)*s, top+float64(p.DY)*s, s, style.color)
+			}
+		}
+	}
+}
+
+// bitmapTextSize returns the size of a text in the pixel font at the given
+// scale, in canvas pixels.
+func (g *Game) bitmapTextSize(text string, scale float64) (float64, float64) {
+	f := g.assets.Font()
+	lines := strings.Split(text, "\n")
+	w := 0
+	for _, line := range lines {
+		_, lineW := f.Line(line)
+		w = max(w, lineW)
+	}
+	return float64(w) * scale, float64(len(lines)*f.LineHeight) * scale
+}
+
+// fontScale returns the scale closest to the given one at which the pixels of
+// the font are whole window pixels. Smaller scales than that of the art keep
+// the text sharp that way.
+func (g *Game) fontScale(scale float64) float64 {
+	s := float64(g.canvas.scale)
+	return max(1, math.Round(scale*s)) / s
+}
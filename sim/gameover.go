@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+)
+
+// endRun is called once the death animation is done. It asks for the player's
+// name if the run made it into the high scores and shows the results.
+func (g *Game) endRun() {
+	g.newScoreIndex = -1
+	if g.HighScores != nil && g.HighScores.Qualifies(g.Difficulty, g.Miles) {
+		g.State = EnteringName
+		g.name = ""
+	} else {
+		g.showGameOver()
+	}
+}
+
+func (g *Game) showGameOver() {
+	g.State = GameOver
+	g.gameOverMenu = menuList{selection: 0}
+}
+
+const (
+	gameOverRetry = iota
+	gameOverMenu
+)
+
+func (g *Game) gameOver() {
+	g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, 0.6))
+
+	textScale := g.fontScale(0.5)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	y := float64(g.canvas.h)/2 - 5*lineH
+
+	g.centerText("GAME OVER", y, g.fontScale(0.75), rgb(255, 255, 200))
+	y += 3 * lineH
+
+	seconds := float64(g.FramesAlive) / 60
+	lines := []string{
+		fmt.Sprintf("DISTANCE\t%.3f MILES", g.Miles),
+		fmt.Sprintf("TOP SPEED\t%.1f MPH", g.TopSpeed*g.milesPerHour()),
+		fmt.Sprintf("TIME ALIVE\t%d:%04.1f", int(seconds/60), math.Mod(seconds, 60)),
+		"DIFFICULTY\t" + difficultyText(g.Difficulty),
+	}
+	tableW := g.tableWidth(lines, textScale)
+	for _, line := range lines {
+		g.tableRow(line, y, tableW, textScale, White)
+		y += lineH
+	}
+	if g.newScoreIndex != -1 {
+		g.centerText(
+			fmt.Sprintf("HIGH SCORE #%d", g.newScoreIndex+1),
+			y, textScale, rgb(255, 255, 200),
+		)
+	}
+	y += 2 * lineH
+
+	switch g.updateMenuList(&g.gameOverMenu, []string{"RETRY", "MENU"}, y, textScale) {
+	case gameOverRetry:
+		g.retry()
+	case gameOverMenu:
+		g.backToMenu()
+	}
+}
+
+// milesPerHour converts the bike speed, given in pixels per frame, to miles per
+// hour. The game runs at 60 frames per second.
+func (g *Game) milesPerHour() float64 {
+	return g.tuning().MilesPerPixel * 60 * 60 * 60
+}
@@ -0,0 +1,123 @@
+package sim
+
+import (
+	"fmt"
+	"strings"
+
+	"city_bike/highscore"
+)
+
+// widestName is the widest name that fits into the high scores, no character
+// of the font is wider than M.
+var widestName = strings.Repeat("M", highscore.MaxNameLength)
+
+// enterName asks for the player's name for the high scores. Going back skips
+// the entry.
+func (g *Game) enterName() {
+	if g.in.Back {
+		g.showGameOver()
+		return
+	}
+
+	for _, r := range g.in.Characters {
+		if ' ' <= r && r < 127 && len(g.name) < highscore.MaxNameLength {
+			g.name += string(r)
+		}
+	}
+	if g.in.Backspace && len(g.name) > 0 {
+		g.name = g.name[:len(g.name)-1]
+	}
+
+	if g.in.Submit && strings.TrimSpace(g.name) != "" {
+		g.newScoreIndex = g.HighScores.Add(highscore.Entry{
+			Name:       strings.TrimSpace(g.name),
+			Miles:      g.Miles,
+			Difficulty: g.Difficulty,
+		})
+		g.newScoreDifficulty = g.Difficulty
+		if g.SaveHighScores != nil {
+			g.SaveHighScores(g.HighScores)
+		}
+		g.showGameOver()
+		return
+	}
+
+	g.blinkTimer++
+
+	g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, 0.6))
+
+	textScale := g.fontScale(0.5)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	y := float64(g.canvas.h)/2 - 3*lineH
+	g.centerText("NEW HIGH SCORE!", y, textScale, rgb(255, 255, 200))
+	y += 2 * lineH
+	g.centerText("ENTER YOUR NAME", y, textScale, White)
+	y += 2 * lineH
+
+	name := g.name
+	if g.blinkTimer/30%2 == 0 {
+		name += "_"
+	}
+	// The name starts where the longest name would, so it does not move
+	// while typing.
+	w, _ := g.bitmapTextSize(widestName+"_", textScale)
+	g.bitmapText(name, (float64(g.canvas.w)-w)/2, y, textStyle{scale: textScale, color: White})
+}
+
+func (g *Game) openHighScores() {
+	g.State = ShowingHighScores
+	g.highScoreDifficulty = g.settings().Difficulty
+}
+
+// showHighScores shows the table of one difficulty level at a time, the
+// player switches between them with left and right.
+func (g *Game) showHighScores() {
+	// Like the settings, the high scores return to the menu as it was, without
+	// fading it in again.
+	if g.in.Confirm || g.in.Submit || g.in.Back || g.in.Clicked {
+		g.State = FadingInMenu
+		return
+	}
+	if g.in.Left {
+		g.highScoreDifficulty = nextDifficulty(g.highScoreDifficulty, -1)
+		g.play(SoundMenuMove)
+	}
+	if g.in.Right {
+		g.highScoreDifficulty = nextDifficulty(g.highScoreDifficulty, 1)
+		g.play(SoundMenuMove)
+	}
+
+	g.rect(0, 0, g.canvas.w, g.canvas.h, rgb(12, 19, 34))
+
+	textScale := g.fontScale(0.4)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	lineH = lineH * 3 / 2
+	y := float64(g.canvas.h)/2 - float64(highscore.MaxEntries+4)*lineH/2
+	g.centerText("HIGH SCORES", y, g.fontScale(0.6), rgb(255, 255, 200))
+	y += 2 * lineH
+	g.centerText("< "+difficultyText(g.highScoreDifficulty)+" >", y, textScale, RGB(0.5, 0.5, 0.5))
+	y += lineH * 3 / 2
+
+	var entries []highscore.Entry
+	if g.HighScores != nil {
+		entries = g.HighScores.Ranking(g.highScoreDifficulty)
+	}
+	// The table is as wide as it gets with the longest names, so it does not
+	// change its width between the difficulty levels.
+	widest := fmt.Sprintf("%d. %s\t%.3f", highscore.MaxEntries, widestName, 999.999)
+	tableW := g.tableWidth([]string{widest}, textScale)
+	for i := range highscore.MaxEntries {
+		line := fmt.Sprintf("%d.\t", i+1)
+		color := RGB(0.5, 0.5, 0.5)
+		if i < len(entries) {
+			e := entries[i]
+			line = fmt.Sprintf("%d. %s\t%.3f", i+1, e.Name, e.Miles)
+			color = White
+		}
+		if i == g.newScoreIndex && g.highScoreDifficulty == g.newScoreDifficulty {
+			color = rgb(255, 255, 200)
+		}
+		g.tableRow(line, y, tableW, textScale, color)
+		y += lineH
+	}
+}
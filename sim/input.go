@@ -0,0 +1,36 @@
+package sim
+
+// Input is the player's input for a single frame, together with the current
+// size of the window that the game is drawn into.
+type Input struct {
+	WindowW int
+	WindowH int
+	MouseX  int
+	MouseY  int
+	// Clicked is true if any mouse button was clicked during the frame.
+	Clicked bool
+	// Confirm is true if a key was pressed that activates the selected menu
+	// item.
+	Confirm bool
+	// Back is true if a key was pressed that leaves the current screen.
+	Back bool
+	// Pause is true if a key was pressed that pauses or resumes the run.
+	Pause      bool
+	Up         bool
+	Down       bool
+	Left       bool
+	Right      bool
+	PedalLeft  bool
+	PedalRight bool
+	Jump       bool
+	LaneUp     bool
+	LaneDown   bool
+	// Characters is the text that was typed during the frame.
+	Characters string
+	Backspace  bool
+	// Submit is true if Enter was pressed, which ends text input.
+	Submit bool
+	// KeysPressed are the names of all keys that were pressed during the
+	// frame, used to bind keys to actions.
+	KeysPressed []string
+}
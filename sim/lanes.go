@@ -0,0 +1,107 @@
+package sim
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// The street has lanes from top to bottom, lane 0 is at the curb where the
+// bike and the car come in.
+const (
+	laneCount = 3
+	// laneDy is the distance between two lanes.
+	laneDy = 6
+	// carLaneOffset is how far below the bike's wheels the car's image starts
+	// in the same lane.
+	carLaneOffset = 3
+)
+
+// laneY is the y coordinate of the bike's wheels in the given lane.
+func laneY(lane int) float64 {
+	return float64(24 - lane*laneDy)
+}
+
+// sameLane reports whether things at the two y coordinates are close enough to
+// collide.
+func sameLane(y1, y2 float64) bool {
+	return math.Abs(y1-y2) < laneDy*2/3
+}
+
+// moveTowards changes y by at most speed towards dest.
+func moveTowards(y, dest, speed float64) float64 {
+	if y < dest {
+		return min(dest, y+speed)
+	}
+	return max(dest, y-speed)
+}
+
+// changeLanes moves the bike to the lane that the player chose.
+func (g *Game) changeLanes() {
+	if !g.Dead {
+		if g.in.LaneUp {
+			g.bikeLane = max(0, g.bikeLane-1)
+		}
+		if g.in.LaneDown {
+			g.bikeLane = min(laneCount-1, g.bikeLane+1)
+		}
+	}
+	g.BikeY = moveTowards(g.BikeY, laneY(g.bikeLane), g.tuning().Lanes.ChangeSpeed)
+}
+
+// steerCar makes the car follow the bike into its lane. The car only changes
+// lanes behind the bike, it does not swerve into the bike from the side. While
+// it is in another lane, it does not pass the bike but falls back behind it.
+func (g *Game) steerCar(carW int) {
+	t := g.tuning().Lanes
+	if g.carLane != g.bikeLane && !g.Dead {
+		if g.CarX+float64(carW) < g.BikeX {
+			g.CarSpeed = min(g.CarSpeed, g.BikeSpeed)
+			g.carLaneTimer++
+			if g.carLaneTimer >= t.CarReactionTime {
+				g.carLane = g.bikeLane
+			}
+		} else {
+			g.CarSpeed = min(g.CarSpeed, t.CarBesideFactor*g.BikeSpeed)
+		}
+	} else {
+		g.carLaneTimer = 0
+	}
+
+	g.CarY = moveTowards(g.CarY, laneY(g.carLane)-carLaneOffset, t.ChangeSpeed)
+}
+
+// sprite is an image in the street that is drawn in the order of the lanes.
+type sprite struct {
+	image string
+	x, y  float64
+	tint  Color
+	// ground is the y coordinate of the lane that the sprite is in.
+	ground float64
+}
+
+func (g *Game) addSprite(image string, x, y, ground float64, tint ...Color) {
+	c := White
+	if len(tint) > 0 {
+		c = tint[0]
+	}
+	g.sprites = append(g.sprites, sprite{
+		image:  image,
+		x:      x,
+		y:      y,
+		tint:   c,
+		ground: ground,
+	})
+}
+
+// drawSprites draws the sprites from the top lane to the bottom lane, so the
+// ones in lower lanes are in front.
+func (g *Game) drawSprites() {
+	slices.SortStableFunc(g.sprites, func(a, b sprite) int {
+		return cmp.Compare(b.ground, a.ground)
+	})
+	for _, s := range g.sprites {
+		g.draw(s.image, s.x, s.y, s.tint)
+	}
+	g.sprites = g.sprites[:0]
+}
@@ -0,0 +1,117 @@
+package sim
+
+// obstacleKind describes a type of obstacle on the street.
+type obstacleKind struct {
+	image string
+	tint  Color
+	// dy is how far the image is drawn above the bike's wheels. Obstacles in
+	// the road have it below 0.
+	dy int
+	// flat obstacles lie in the road, every jump clears them. The others must
+	// be jumped higher than they are tall.
+	flat bool
+	// crash is set if hitting the obstacle ends the run. Otherwise the bike's
+	// speed is multiplied by slowdown.
+	crash    bool
+	slowdown float64
+	// parked obstacles are always in lane 0, at the curb.
+	parked bool
+}
+
+var obstacleKinds = []obstacleKind{
+	{image: "pothole", tint: White, dy: -1, flat: true, slowdown: 0.7},
+	{image: "puddle", tint: White, dy: -1, flat: true, slowdown: 0.85},
+	{image: "trashcan_fallen", tint: White, crash: true},
+	// Parked cars are too tall to jump over, the bike has to change lanes.
+	// They are blue, which is baked into the atlas, see rsc/tints.json.
+	{image: "car_0_parked", tint: White, dy: -carLaneOffset, slowdown: 0.5, parked: true},
+}
+
+// obstacleSpacing is the length of the street that has at most one obstacle.
+const obstacleSpacing = 200
+
+// obstaclesAhead is how far in front of the bike the obstacles start when the
+// player takes control.
+const obstaclesAhead = 400
+
+type obstacle struct {
+	obstacleKind
+	x    int
+	lane int
+}
+
+// obstacle returns the obstacle in the i'th part of the street, if there is
+// one.
+func (w *world) obstacle(i int) (obstacle, bool) {
+	r := w.rand(layerObstacles, i)
+	if r.Intn(2) == 0 {
+		return obstacle{}, false
+	}
+	o := obstacle{
+		obstacleKind: obstacleKinds[r.Intn(len(obstacleKinds))],
+		x:            i*obstacleSpacing + r.Intn(obstacleSpacing/2),
+		lane:         r.Intn(laneCount),
+	}
+	if o.parked {
+		o.lane = 0
+	}
+	return o, true
+}
+
+// jump moves the bike through the air after the player jumped.
+func (g *Game) jump() {
+	bike := g.tuning().Bike
+	if g.in.Jump && g.jumpZ == 0 && !g.Dead {
+		g.jumpVelocity = bike.JumpSpeed
+		g.play(SoundJump)
+	}
+	g.jumpZ += g.jumpVelocity
+	g.jumpVelocity -= bike.JumpGravity
+	if g.jumpZ <= 0 {
+		g.jumpZ = 0
+		g.jumpVelocity = 0
+	}
+}
+
+// drawObstacles adds the obstacles between left and right to the sprites and
+// checks whether the bike hits one of them.
+func (g *Game) drawObstacles(city *world, left, right int) {
+	if g.obstaclesFrom == 0 {
+		return
+	}
+
+	bikeW, _ := g.size("bike_0")
+	for i := floorDiv(left, obstacleSpacing) - 1; i*obstacleSpacing < right; i++ {
+		o, ok := city.obstacle(i)
+		if !ok || float64(o.x) < g.obstaclesFrom {
+			continue
+		}
+		w, h := g.size(o.image)
+		y := laneY(o.lane)
+		g.addSprite(o.image, float64(o.x), y+float64(o.dy), y, o.tint)
+
+		if g.Dead || i <= g.lastObstacle {
+			continue
+		}
+		overlaps := g.BikeX < float64(o.x+w) && float64(o.x) < g.BikeX+float64(bikeW) &&
+			sameLane(g.BikeY, y)
+		cleared := g.jumpZ > 0 && (o.flat || g.jumpZ > float64(h))
+		if overlaps && !cleared {
+			g.lastObstacle = i
+			g.hitObstacle(o)
+		}
+	}
+}
+
+func (g *Game) hitObstacle(o obstacle) {
+	if o.crash {
+		g.Dead = true
+		g.deathAnim.Play(g.clip("death"), g.endRun)
+		g.crashX = g.BikeX
+		g.play(SoundCrash)
+		g.cam.shakeBy(g.tuning().Camera.CrashShake)
+	} else {
+		g.BikeSpeed *= o.slowdown
+		g.play(SoundBump)
+	}
+}
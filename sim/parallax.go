@@ -0,0 +1,64 @@
+package sim
+
+import "city_bike/tuning"
+
+// The layers of scenery behind the city are in the tuning, see
+// tuning.ParallaxLayer.
+
+// parallaxItem is an image in a parallax layer, or a star if it has no image.
+type parallaxItem struct {
+	drawItem
+	tint Color
+}
+
+// parallaxMargin is how far left of the view items are still drawn, since
+// their images reach into it.
+const parallaxMargin = 40
+
+// drawParallax draws the parallax layers from back to front. An item at x in a
+// layer is drawn at the world position that puts it scroll times the camera's
+// offset into the view. Layers only scroll sideways.
+func (g *Game) drawParallax(city *world, streetH, visibleW int, day daytime) {
+	camX := g.cam.x
+	for index, layer := range g.tuning().Parallax {
+		stars := len(layer.Images) == 0
+		if stars && day.stars == 0 {
+			continue
+		}
+
+		left := camX * layer.Scroll
+		offsetX := camX * (1 - layer.Scroll)
+		first := floorDiv(round(left)-parallaxMargin, layer.Spacing)
+		for i := first; float64(i*layer.Spacing) < left+float64(visibleW); i++ {
+			item := city.parallaxItem(index, layer, i)
+			x := float64(i*layer.Spacing+item.dx) + offsetX
+			y := streetH + layer.Y + item.dy
+			if stars {
+				c := item.tint
+				c.A *= day.stars
+				screenX, screenY := g.worldToScreen(x, y)
+				g.rect(screenX, screenY, g.canvas.pixel(), g.canvas.pixel(), c)
+			} else {
+				g.draw(item.imageName, x, y, day.lit(item.tint))
+			}
+		}
+	}
+}
+
+// parallaxItem returns the item at the i'th spot of the layer with the given
+// index.
+func (w *world) parallaxItem(index int, layer tuning.ParallaxLayer, i int) parallaxItem {
+	r := w.rand(layerParallax+index, i)
+	item := parallaxItem{
+		drawItem: drawItem{
+			dx: layer.DX[0] + r.Intn(layer.DX[1]-layer.DX[0]+1),
+			dy: layer.DY[0] + r.Intn(layer.DY[1]-layer.DY[0]+1),
+		},
+	}
+	if len(layer.Images) > 0 {
+		item.imageName = layer.Images[r.Intn(len(layer.Images))]
+	}
+	v := layer.TintVariation * r.Float32()
+	item.tint = RGB(layer.Tint[0]+v, layer.Tint[1]+v, layer.Tint[2]+v)
+	return item
+}
@@ -0,0 +1,54 @@
+package sim
+
+func (g *Game) pause() {
+	g.pausedState = g.State
+	g.State = Paused
+	g.pauseMenu = menuList{}
+	// The key that paused the game must not resume it right away.
+	g.in.Pause = false
+	g.in.Back = false
+}
+
+func (g *Game) resume() {
+	g.State = g.pausedState
+}
+
+const (
+	pauseResume = iota
+	pauseRestart
+	pauseSettings
+	pauseMenu
+	pauseQuit
+)
+
+// paused draws the last frame of the run, dimmed, with the pause menu on top.
+// Nothing else is updated so the run is frozen.
+func (g *Game) paused() {
+	g.commands = append(g.commands, g.frozen...)
+	g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, 0.6))
+
+	textScale := g.fontScale(0.5)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	y := float64(g.canvas.h)/2 - 4*lineH
+	g.centerText("PAUSED", y, g.fontScale(0.75), rgb(255, 255, 200))
+	y += 3 * lineH
+
+	items := []string{"RESUME", "RESTART", "SETTINGS", "MENU", "QUIT"}
+	choice := g.updateMenuList(&g.pauseMenu, items, y, textScale)
+	if g.in.Pause || g.in.Back {
+		choice = pauseResume
+	}
+
+	switch choice {
+	case pauseResume:
+		g.resume()
+	case pauseRestart:
+		g.retry()
+	case pauseSettings:
+		g.openSettings()
+	case pauseMenu:
+		g.backToMenu()
+	case pauseQuit:
+		g.Quit = true
+	}
+}
@@ -0,0 +1,164 @@
+package sim
+
+import "math"
+
+type pickupKind int
+
+const (
+	// pickupBoost raises the bike's top speed for a while.
+	pickupBoost pickupKind = iota
+	// pickupShield saves the bike from the car once.
+	pickupShield
+	// pickupSlow slows the car down for a while.
+	pickupSlow
+	pickupKindCount
+)
+
+var pickupImages = [pickupKindCount]string{
+	pickupBoost:  "pickup_boost",
+	pickupShield: "pickup_shield",
+	pickupSlow:   "pickup_slow",
+}
+
+const (
+	// pickupSpacing is the length of the street that has at most one pickup.
+	// It is a multiple of obstacleSpacing and pickups are placed in the second
+	// half of the first obstacle part, where obstacles only start. Wide
+	// obstacles reach into it though, so the pickup is never in the lane of
+	// that obstacle.
+	pickupSpacing = 3 * obstacleSpacing
+)
+
+type pickup struct {
+	kind pickupKind
+	x    int
+	lane int
+}
+
+// pickup returns the pickup in the i'th part of the street, if there is one.
+func (w *world) pickup(i int) (pickup, bool) {
+	r := w.rand(layerPickups, i)
+	if r.Intn(2) == 0 {
+		return pickup{}, false
+	}
+	kind := pickupKind(r.Intn(int(pickupKindCount)))
+	p := pickup{
+		kind: kind,
+		x:    i*pickupSpacing + obstacleSpacing/2 + r.Intn(obstacleSpacing/3),
+		lane: r.Intn(laneCount),
+	}
+	if o, ok := w.obstacle(i * pickupSpacing / obstacleSpacing); ok && o.lane == p.lane {
+		p.lane = (p.lane + 1) % laneCount
+	}
+	return p, true
+}
+
+// drawPickups adds the pickups between left and right, that were not picked
+// up yet, to the sprites and lets the bike collect them.
+func (g *Game) drawPickups(city *world, left, right int) {
+	if g.obstaclesFrom == 0 {
+		return
+	}
+
+	bikeW, _ := g.size("bike_0")
+	for i := floorDiv(left, pickupSpacing) - 1; i*pickupSpacing < right; i++ {
+		p, ok := city.pickup(i)
+		if !ok || float64(p.x) < g.obstaclesFrom || i <= g.lastPickup {
+			continue
+		}
+
+		image := pickupImages[p.kind]
+		w, _ := g.size(image)
+		y := laneY(p.lane)
+		bob := math.Round(1.5 + 1.5*math.Sin(float64(g.FramesAlive+p.x)/10))
+		g.addSprite(image, float64(p.x), y+2+bob, y)
+
+		overlaps := g.BikeX < float64(p.x+w) && float64(p.x) < g.BikeX+float64(bikeW) &&
+			sameLane(g.BikeY, y)
+		if overlaps && !g.Dead {
+			g.lastPickup = i
+			g.collect(p.kind)
+		}
+	}
+}
+
+func (g *Game) collect(kind pickupKind) {
+	g.play(SoundPickup)
+	t := g.tuning().Pickups
+	switch kind {
+	case pickupBoost:
+		g.boostTimer = t.BoostDuration
+		g.BikeSpeed = min(t.BoostedMaxSpeed, g.BikeSpeed*t.BoostKick)
+	case pickupShield:
+		g.shield = true
+	case pickupSlow:
+		g.slowCarTimer = t.SlowCarDuration
+	}
+}
+
+// updatePickups counts down the time that the pickups are active.
+func (g *Game) updatePickups() {
+	g.boostTimer = max(0, g.boostTimer-1)
+	g.slowCarTimer = max(0, g.slowCarTimer-1)
+}
+
+func (g *Game) maxBikeSpeed() float64 {
+	if g.boostTimer > 0 {
+		return g.tuning().Pickups.BoostedMaxSpeed
+	}
+	return g.tuning().Bike.MaxSpeed
+}
+
+// carSpeedFactor is the factor on the car's speed when moving it.
+func (g *Game) carSpeedFactor() float64 {
+	if g.slowCarTimer > 0 {
+		return g.tuning().Pickups.SlowCarFactor
+	}
+	return 1
+}
+
+// useShield is called when the car catches the bike. If the bike has a shield,
+// the shield breaks and the car is knocked back, otherwise it returns false.
+func (g *Game) useShield(carW int) bool {
+	if !g.shield {
+		return false
+	}
+	g.shield = false
+	g.CarX = g.BikeX - float64(carW) - g.tuning().Pickups.ShieldKnockBack
+	g.play(SoundBump)
+	return true
+}
+
+// drawActivePickups shows the active pickups in the top-left corner of the
+// screen, the timed ones with a bar of their remaining time.
+func (g *Game) drawActivePickups() {
+	type active struct {
+		kind pickupKind
+		// left is the remaining time from 0 to 1, it is 1 for the shield.
+		left float64
+	}
+	t := g.tuning().Pickups
+	var list []active
+	if g.boostTimer > 0 {
+		list = append(list, active{pickupBoost, float64(g.boostTimer) / float64(t.BoostDuration)})
+	}
+	if g.shield {
+		list = append(list, active{pickupShield, 1})
+	}
+	if g.slowCarTimer > 0 {
+		list = append(list, active{pickupSlow, float64(g.slowCarTimer) / float64(t.SlowCarDuration)})
+	}
+
+	margin := 5
+	x := margin
+	for _, a := range list {
+		image := pickupImages[a.kind]
+		w, h := g.size(image)
+		g.image(image, x, margin, 1)
+		barY := margin + h + 1
+		barH := 1
+		g.rect(x, barY, w, barH, RGBA(1, 1, 1, 0.3))
+		g.rect(x, barY, float64(w)*a.left, barH, White)
+		x += w + margin
+	}
+}
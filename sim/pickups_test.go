@@ -0,0 +1,88 @@
+package sim
+
+import "testing"
+
+func TestCollectPickups(t *testing.T) {
+	maxSpeed := (&Game{}).tuning().Bike.MaxSpeed
+	pickups := (&Game{}).tuning().Pickups
+	boosted := pickups.BoostedMaxSpeed
+	tests := []struct {
+		kind         pickupKind
+		speed        float64
+		wantSpeed    float64
+		wantMaxSpeed float64
+		wantCar      float64
+		wantShield   bool
+	}{
+		{kind: pickupBoost, speed: 1, wantSpeed: pickups.BoostKick, wantMaxSpeed: boosted, wantCar: 1},
+		// The kick does not go beyond the boosted top speed.
+		{kind: pickupBoost, speed: boosted - 0.1, wantSpeed: boosted, wantMaxSpeed: boosted, wantCar: 1},
+		{kind: pickupSlow, speed: 1, wantSpeed: 1, wantMaxSpeed: maxSpeed, wantCar: pickups.SlowCarFactor},
+		{kind: pickupShield, speed: 1, wantSpeed: 1, wantMaxSpeed: maxSpeed, wantCar: 1, wantShield: true},
+	}
+	for _, tt := range tests {
+		g := &Game{}
+		g.BikeSpeed = tt.speed
+		g.collect(tt.kind)
+
+		if g.BikeSpeed != tt.wantSpeed {
+			t.Errorf("%s at speed %v: speed is %v, want %v", pickupImages[tt.kind], tt.speed, g.BikeSpeed, tt.wantSpeed)
+		}
+		if got := g.maxBikeSpeed(); got != tt.wantMaxSpeed {
+			t.Errorf("%s: top speed is %v, want %v", pickupImages[tt.kind], got, tt.wantMaxSpeed)
+		}
+		if got := g.carSpeedFactor(); got != tt.wantCar {
+			t.Errorf("%s: car speed factor is %v, want %v", pickupImages[tt.kind], got, tt.wantCar)
+		}
+		if g.shield != tt.wantShield {
+			t.Errorf("%s: shield is %v, want %v", pickupImages[tt.kind], g.shield, tt.wantShield)
+		}
+	}
+}
+
+func TestTimedPickupsRunOut(t *testing.T) {
+	g := &Game{}
+	g.collect(pickupBoost)
+	g.collect(pickupSlow)
+	pickups := g.tuning().Pickups
+	for range max(pickups.BoostDuration, pickups.SlowCarDuration) {
+		g.updatePickups()
+	}
+	if got, want := g.maxBikeSpeed(), g.tuning().Bike.MaxSpeed; got != want {
+		t.Errorf("top speed is %v after the boost ran out, want %v", got, want)
+	}
+	if got := g.carSpeedFactor(); got != 1 {
+		t.Errorf("car speed factor is %v after the slow down ran out, want 1", got)
+	}
+}
+
+func TestShieldSavesOnce(t *testing.T) {
+	const carW = 56
+	g := &Game{}
+	g.BikeX = 1000
+	g.CarX = 990
+	g.collect(pickupShield)
+
+	if !g.useShield(carW) {
+		t.Fatal("the shield did not save the bike")
+	}
+	if want := g.BikeX - carW - g.tuning().Pickups.ShieldKnockBack; g.CarX != want {
+		t.Errorf("the car was knocked back to %v, want %v", g.CarX, want)
+	}
+	if g.useShield(carW) {
+		t.Error("the shield saved the bike twice")
+	}
+}
+
+func TestPickupsAvoidObstacleLanes(t *testing.T) {
+	w := &world{seed: testSeed}
+	for i := range 1000 {
+		p, ok := w.pickup(i)
+		if !ok {
+			continue
+		}
+		if o, ok := w.obstacle(i * pickupSpacing / obstacleSpacing); ok && o.lane == p.lane {
+			t.Errorf("pickup %d at %d is in the lane of the obstacle at %d", i, p.x, o.x)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package sim
+
+import "city_bike/tuning"
+
+// pursuer is a vehicle that chases the bike. Every kind of vehicle has its own
+// images and its own way of driving.
+type pursuer interface {
+	// clip is the name of the pursuer's driving animation.
+	clip() string
+	// crashDx is where the bike crashes into the pursuer, relative to the
+	// pursuer's left edge.
+	crashDx() float64
+	// drive returns the pursuer's speed for the next frame.
+	drive(c chase) float64
+}
+
+// chase is what a pursuer knows about the chase in a frame.
+type chase struct {
+	// speed is the pursuer's current speed.
+	speed     float64
+	bikeSpeed float64
+	// gap is how far the pursuer's front is behind the bike. It is negative
+	// if they overlap.
+	gap      float64
+	sameLane bool
+	miles    float64
+	// milestone is the number of milestones that the bike passed.
+	milestone int
+	// car is the pursuer's behavior at the difficulty level of the run.
+	car     tuning.Car
+	pursuer tuning.Pursuer
+}
+
+// pursuer returns the vehicle that chases the bike in this run.
+func (g *Game) pursuer() pursuer {
+	if g.chaser == nil {
+		g.chaser = &car{}
+	}
+	return g.chaser
+}
+
+// pursue lets the pursuer decide on its speed.
+func (g *Game) pursue(carW int) {
+	g.CarSpeed = g.pursuer().drive(chase{
+		speed:     g.CarSpeed,
+		bikeSpeed: g.BikeSpeed,
+		gap:       g.BikeX - (g.CarX + float64(carW)),
+		sameLane:  g.carLane == g.bikeLane,
+		miles:     g.Miles,
+		milestone: g.milestone,
+		car:       g.tuning().Car.For(g.Difficulty),
+		pursuer:   g.tuning().Pursuer,
+	})
+}
+
+type carState int
+
+const (
+	// carCruise follows the bike at its speed.
+	carCruise carState = iota
+	// carCloseGap speeds up to catch up with a bike that is far ahead.
+	carCloseGap
+	// carLunge is a short burst of speed right behind the bike.
+	carLunge
+	// carFallBack brakes after the bike slowed down unexpectedly, like a
+	// driver who is surprised by the bike braking.
+	carFallBack
+)
+
+// car is the red sports car that chases the bike.
+type car struct {
+	state carState
+	// timer counts down the frames left in the lunge and fall back states.
+	timer         int
+	lungeCooldown int
+	lastBikeSpeed float64
+}
+
+func (*car) clip() string {
+	return "car"
+}
+
+func (*car) crashDx() float64 {
+	return 43
+}
+
+func (p *car) drive(c chase) float64 {
+	t := c.pursuer
+	braked := c.bikeSpeed < p.lastBikeSpeed*(1-t.BrakeDrop)
+	p.lastBikeSpeed = c.bikeSpeed
+	p.timer = max(0, p.timer-1)
+	p.lungeCooldown = max(0, p.lungeCooldown-1)
+
+	busy := (p.state == carLunge || p.state == carFallBack) && p.timer > 0
+	if braked && c.gap > 0 {
+		p.state = carFallBack
+		p.timer = t.FallBackDuration
+	} else if !busy {
+		switch {
+		case c.sameLane && 0 < c.gap && c.gap < t.LungeDistance && p.lungeCooldown == 0:
+			p.state = carLunge
+			p.timer = t.LungeDuration
+			p.lungeCooldown = t.LungeCooldown
+		case c.gap > t.CloseGapDistance:
+			p.state = carCloseGap
+		default:
+			p.state = carCruise
+		}
+	}
+
+	// The car gets faster the further the bike rides and in every new zone.
+	escalation := c.car.Escalation*max(0, c.miles-c.car.EscalationFrom) +
+		t.ZoneSpeedup*float64(c.milestone)
+	target := c.bikeSpeed * (1 + escalation)
+
+	switch p.state {
+	case carCloseGap:
+		target = target*(1+t.CloseGapBoost) + c.car.RubberBand*c.gap
+	case carLunge:
+		target += t.LungeSpeed
+	case carFallBack:
+		// Falling back is the only time that the car drives slower than its
+		// minimum speed.
+		return blend(c.speed, c.bikeSpeed*t.FallBackFactor, t.BrakeBlend)
+	}
+
+	speed := blend(c.speed, target, c.car.FallBackBlend)
+	if c.speed < target {
+		speed = blend(c.speed, target, c.car.CatchUpBlend)
+	}
+	return max(c.car.MinSpeed, speed)
+}
@@ -0,0 +1,112 @@
+package sim
+
+// Command is a single entry of the render list that Game.Step returns. All
+// positions and sizes are in window pixels.
+type Command struct {
+	Kind CommandKind
+	// Image is the name of the image to draw, without the ".png" extension.
+	Image string
+	X     float64
+	Y     float64
+	W     int
+	H     int
+	Scale float64
+	// Color is the tint for DrawImage and the fill color for FillRect.
+	Color Color
+	// Colors are the corner colors for FillRectTint, clockwise from the
+	// top-left.
+	Colors [4]Color
+}
+
+type CommandKind int
+
+const (
+	DrawImage CommandKind = iota
+	FillRect
+	FillRectTint
+)
+
+// Color has the same layout as draw.Color so it can be converted directly.
+type Color struct{ R, G, B, A float32 }
+
+var White = Color{1, 1, 1, 1}
+
+func RGB(r, g, b float32) Color {
+	return Color{r, g, b, 1}
+}
+
+func RGBA(r, g, b, a float32) Color {
+	return Color{r, g, b, a}
+}
+
+func rgb(r, g, b byte) Color {
+	return RGB(
+		float32(r)/255,
+		float32(g)/255,
+		float32(b)/255,
+	)
+}
+
+// image draws an image in canvas coordinates, scale is the size of its pixels
+// in canvas pixels.
+func (g *Game) image(imageName string, x, y any, scale any, tint ...Color) {
+	c := White
+	if len(tint) > 0 {
+		c = tint[0]
+	}
+	windowX, windowY := g.canvas.toWindow(x, y)
+	g.commands = append(g.commands, Command{
+		Kind:  DrawImage,
+		Image: imageName,
+		X:     windowX,
+		Y:     windowY,
+		Scale: toFloat64(scale) * float64(g.canvas.scale),
+		Color: c,
+	})
+}
+
+// rect fills a rectangle in canvas coordinates.
+func (g *Game) rect(x, y, w, h any, c Color) {
+	windowX, windowY, windowW, windowH := g.canvas.windowRect(x, y, w, h)
+	g.commands = append(g.commands, Command{
+		Kind:  FillRect,
+		X:     float64(windowX),
+		Y:     float64(windowY),
+		W:     windowW,
+		H:     windowH,
+		Color: c,
+	})
+}
+
+// rectTint fills a rectangle in canvas coordinates with a color gradient.
+func (g *Game) rectTint(x, y, w, h any, colors [4]Color) {
+	windowX, windowY, windowW, windowH := g.canvas.windowRect(x, y, w, h)
+	g.commands = append(g.commands, Command{
+		Kind:   FillRectTint,
+		X:      float64(windowX),
+		Y:      float64(windowY),
+		W:      windowW,
+		H:      windowH,
+		Colors: colors,
+	})
+}
+
+// fillRect fills a rectangle in world coordinates, x and y are its bottom-left
+// corner.
+func (g *Game) fillRect(x, y, w, h any, c Color) {
+	canvasX, canvasY := g.worldToScreen(x, toFloat64(y)+toFloat64(h))
+	g.rect(canvasX, canvasY, toFloat64(w)*g.cam.zoom, toFloat64(h)*g.cam.zoom, c)
+}
+
+// worldToScreen returns the canvas position of a world position.
+func (g *Game) worldToScreen(x, y any) (float64, float64) {
+	return g.cam.toScreen(toFloat64(x), toFloat64(y))
+}
+
+// draw draws an image in world coordinates, x and y are its bottom-left
+// corner.
+func (g *Game) draw(imageName string, x, y any, tint ...Color) {
+	_, imageH := g.size(imageName)
+	screenX, screenY := g.cam.toScreen(toFloat64(x), toFloat64(y)+float64(imageH))
+	g.image(imageName, screenX, screenY, g.cam.zoom, tint...)
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"fmt"
+
+	"city_bike/settings"
+)
+
+func (g *Game) openSettings() {
+	g.settingsReturn = g.State
+	g.State = ShowingSettings
+	g.settingsMenu = menuList{}
+}
+
+const (
+	settingFullscreen = iota
+	settingWindowSize
+	settingFitHeight
+	settingPixelScale
+	settingVolume
+	settingDailyCity
+	settingControls
+	settingBack
+)
+
+func (g *Game) showSettings() {
+	if g.settingsReturn == Paused {
+		g.commands = append(g.commands, g.frozen...)
+		g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, 0.6))
+	} else {
+		g.rect(0, 0, g.canvas.w, g.canvas.h, rgb(12, 19, 34))
+	}
+
+	s := g.settings()
+
+	onOff := map[bool]string{true: "ON", false: "OFF"}
+	items := []string{
+		settingFullscreen: "FULLSCREEN\t" + onOff[s.Fullscreen],
+		settingWindowSize: fmt.Sprintf("WINDOW SIZE\t%dx%d", s.WindowW, s.WindowH),
+		settingFitHeight:  "FIT HEIGHT\t" + onOff[s.FitHeight],
+		settingPixelScale: "PIXEL SCALE\t" + pixelScaleText(s.PixelScale),
+		settingVolume:     fmt.Sprintf("VOLUME\t%d%%", round(s.Volume*100)),
+		settingDailyCity:  "DAILY CITY\t" + onOff[s.DailyCity],
+		settingControls:   "CONTROLS",
+		settingBack:       "BACK",
+	}
+
+	textScale := g.fontScale(0.5)
+	_, lineH := g.bitmapTextSize("A", textScale)
+	y := float64(g.canvas.h)/2 - 5*lineH
+	g.centerText("SETTINGS", y, g.fontScale(0.75), rgb(255, 255, 200))
+	y += 3 * lineH
+
+	activated := g.updateMenuList(&g.settingsMenu, items, y, textScale)
+	if g.settingsMenu.selection == settingWindowSize {
+		g.centerText("(takes effect after restarting)", y+float64(len(items))*lineH*3/2+lineH/2, g.fontScale(0.25), RGB(0.5, 0.5, 0.5))
+	}
+
+	dir := 0
+	if activated != -1 || g.in.Right {
+		dir = 1
+	}
+	if g.in.Left {
+		dir = -1
+	}
+	if g.in.Back || activated == settingBack {
+		g.State = g.settingsReturn
+		return
+	}
+	if activated == settingControls {
+		g.openControls()
+		return
+	}
+	if dir == 0 {
+		return
+	}
+
+	switch g.settingsMenu.selection {
+	case settingFullscreen:
+		s.Fullscreen = !s.Fullscreen
+	case settingWindowSize:
+		i := 0
+		for j, size := range settings.WindowSizes {
+			if size == [2]int{s.WindowW, s.WindowH} {
+				i = j
+			}
+		}
+		n := len(settings.WindowSizes)
+		i = (i + dir + n) % n
+		s.WindowW, s.WindowH = settings.WindowSizes[i][0], settings.WindowSizes[i][1]
+	case settingFitHeight:
+		s.FitHeight = !s.FitHeight
+	case settingPixelScale:
+		n := settings.MaxPixelScale + 1
+		s.PixelScale = (s.PixelScale + dir + n) % n
+	case settingVolume:
+		s.Volume = min(1, max(0, float64(round(s.Volume*10)+dir)/10))
+	case settingDailyCity:
+		s.DailyCity = !s.DailyCity
+	case settingControls, settingBack:
+		return
+	}
+
+	if activated == -1 {
+		g.play(SoundMenuMove)
+	}
+	g.saveSettings()
+}
+
+func pixelScaleText(scale int) string {
+	if scale == 0 {
+		return "AUTO"
+	}
+	return fmt.Sprintf("MAX %d", scale)
+}
+
+func (g *Game) saveSettings() {
+	if g.SaveSettings != nil {
+		g.SaveSettings(g.settings())
+	}
+}
+
+// settings returns the player's settings, or the defaults if none were set.
+func (g *Game) settings() *settings.Settings {
+	if g.Settings == nil {
+		g.Settings = settings.Default()
+	}
+	return g.Settings
+}
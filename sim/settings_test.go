@@ -0,0 +1,42 @@
+package sim
+
+import (
+	"testing"
+
+	"city_bike/settings"
+)
+
+// clickAt sets up a click at the canvas position of a mouse that rests there
+// since the last frame.
+func clickAt(g *Game, m *menuList, x, y int) {
+	g.in = Input{MouseX: x, MouseY: y, Clicked: true}
+	m.lastMouseX, m.lastMouseY = x, y
+}
+
+func TestClickChangesClickedSetting(t *testing.T) {
+	assets := loadTestAssets(t)
+	toggled := false
+	for y := range canvasH {
+		g := New(assets)
+		g.Step(Input{WindowW: testWindowW, WindowH: testWindowH})
+		g.openSettings()
+		g.settingsMenu.selection = settingVolume
+		volume := g.settings().Volume
+
+		clickAt(g, &g.settingsMenu, g.canvas.w/2, y)
+		g.showSettings()
+
+		if g.settings().Volume != volume {
+			t.Fatalf("a click at %d changed the selected volume", y)
+		}
+		if g.settings().Fullscreen != settings.Default().Fullscreen {
+			toggled = true
+			if g.settingsMenu.selection != settingFullscreen {
+				t.Errorf("a click at %d toggled fullscreen but selected %d", y, g.settingsMenu.selection)
+			}
+		}
+	}
+	if !toggled {
+		t.Error("no click toggled fullscreen")
+	}
+}
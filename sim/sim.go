@@ -0,0 +1,785 @@
+// Package sim contains the complete game logic of City Bike. It does not
+// depend on a window, instead Game.Step is called once per frame with the
+// player's Input and returns the list of things to draw for that frame. This
+// way the game can be run headless, e.g. in tests.
+package sim
+
+import (
+	"fmt"
+
+	"city_bike/anim"
+	"city_bike/font"
+	"city_bike/highscore"
+	"city_bike/settings"
+	"city_bike/tuning"
+
+	"github.com/gonutz/ease"
+)
+
+var (
+	frontYardColor = rgb(38, 38, 38)
+	milesColor     = rgb(79, 181, 255)
+)
+
+// Game is the state of a single game. Create it with New and advance it one
+// frame at a time with Step.
+type Game struct {
+	runState
+
+	State State
+	// Runs counts the runs that were started, including retries.
+	Runs int
+	// Quit is set when the player chose to quit the game.
+	Quit bool
+
+	// HighScores is the table that finished runs are entered into. If it is
+	// nil, no names are asked for after a run.
+	HighScores *highscore.Table
+	// SaveHighScores is called after a new entry was added to HighScores.
+	SaveHighScores func(*highscore.Table)
+	// Settings are the player's preferences. If nil, the defaults are used.
+	Settings *settings.Settings
+	// SaveSettings is called after the player changed the settings.
+	SaveSettings func(*settings.Settings)
+	// NewSeed returns the seed for the city of a new run. If it is nil, every
+	// run takes place in the same city.
+	NewSeed func() int64
+	// Tuning are the numbers that balance the game. If nil, the defaults are
+	// used.
+	Tuning *tuning.Tuning
+
+	assets         Assets
+	in             Input
+	commands       []Command
+	audio          Audio
+	world          world
+	sprites        []sprite
+	canvas         canvas
+	menuSelection  int
+	lastMouseX     int
+	lastMouseY     int
+	name           string
+	newScoreIndex  int
+	blinkTimer     int
+	gameOverMenu   menuList
+	pauseMenu      menuList
+	pausedState    State
+	settingsMenu   menuList
+	settingsReturn State
+	controlsMenu   menuList
+	rebinding      bool
+	// frozen is the render list of the last frame before pausing.
+	frozen []Command
+	// newScoreDifficulty is the difficulty level of the table that
+	// newScoreIndex is in.
+	newScoreDifficulty string
+	// highScoreDifficulty is the difficulty level of the table that is shown
+	// on the high scores screen.
+	highScoreDifficulty string
+}
+
+// runState is everything that is reset when going back to the menu.
+type runState struct {
+	BikeSpeed float64
+	BikeX     float64
+	BikeY     float64
+	CarSpeed  float64
+	CarX      float64
+	CarY      float64
+	Miles     float64
+	Dead      bool
+	// TopSpeed is the highest bike speed reached while playing.
+	TopSpeed float64
+	// FramesAlive counts the frames played before the car caught the bike.
+	FramesAlive int
+	// Seed is what the city of the run is generated from.
+	Seed int64
+	// Difficulty is the name of the difficulty level of the run.
+	Difficulty string
+
+	cam            camera
+	fade           float32
+	zoomTimer      int
+	bikeAnim       anim.Animator
+	carAnim        anim.Animator
+	arrowHintTimer int
+	nextKeyLeft    bool
+	deathAnim      anim.Animator
+	jumpZ          float64
+	jumpVelocity   float64
+	// obstaclesFrom is where the obstacles start, 0 before the player takes
+	// control.
+	obstaclesFrom float64
+	// lastObstacle is the index of the last obstacle that the bike hit.
+	lastObstacle int
+	// crashX is where the bike crashed into an obstacle, 0 if it did not.
+	crashX       float64
+	bikeLane     int
+	carLane      int
+	carLaneTimer int
+	lastPickup   int
+	boostTimer   int
+	slowCarTimer int
+	shield       bool
+	chaser       pursuer
+	// milestone is the number of milestones that the bike passed.
+	milestone    int
+	bannerTimer  int
+	weatherFrame int
+	// fatigue is how tired the rider is, see stamina.go.
+	fatigue     float64
+	exhausted   bool
+	sinceStroke int
+}
+
+// State is the phase that the game is in. A run goes through the states from
+// FadingInGame to Playing in order.
+type State int
+
+const (
+	FadingInMenu State = iota
+	FadingOutMenu
+	ShowingHighScores
+	FadingInGame
+	AscendingIntoGame
+	ZoomingIntoGame
+	BikeComingIn
+	CarComingIn
+	Playing
+	EnteringName
+	GameOver
+	Paused
+	ShowingSettings
+	ShowingControls
+)
+
+// Assets reports the sizes of images and the font, which the game needs for
+// its layout. Image names do not contain the ".png" extension.
+type Assets interface {
+	ImageSize(name string) (width, height int)
+	// Font is the pixel font, whose glyph images must be available by the
+	// names of Font.GlyphImage.
+	Font() *font.Font
+	// Animations are the sprite animations of the bike, the car and the
+	// crash.
+	Animations() anim.Clips
+}
+
+// New creates a game that starts at the menu.
+func New(assets Assets) *Game {
+	g := &Game{assets: assets, newScoreIndex: -1}
+	g.backToMenu()
+	return g
+}
+
+// NewRun creates a game that skips the menu and starts right at the intro of
+// a run in the city of the given seed at the given difficulty level. This is
+// used to replay recorded runs.
+func NewRun(assets Assets, seed int64, difficulty string) *Game {
+	g := New(assets)
+	g.startRun()
+	g.Seed = seed
+	g.Difficulty = difficulty
+	return g
+}
+
+// Running reports whether a run is being played, i.e. the menu is done and the
+// run is neither paused nor over yet.
+func (g *Game) Running() bool {
+	return FadingInGame <= g.State && g.State <= Playing
+}
+
+// Paused reports whether the run is paused. Nothing in the run moves while
+// paused.
+func (g *Game) Paused() bool {
+	return g.State == Paused
+}
+
+func (g *Game) inMenu() bool {
+	return g.State == FadingInMenu || g.State == FadingOutMenu
+}
+
+// Step advances the game by one frame and returns what to draw, in order, for
+// this frame.
+func (g *Game) Step(in Input) []Command {
+	s := g.settings()
+	g.canvas = newCanvas(in.WindowW, in.WindowH, s.FitHeight, s.PixelScale)
+	in.MouseX, in.MouseY = g.canvas.fromWindow(in.MouseX, in.MouseY)
+	g.in = in
+	g.commands = nil
+	g.audio = Audio{}
+
+	if g.Running() && g.in.Pause {
+		g.pause()
+	}
+
+	if g.State == Paused {
+		g.paused()
+	} else if g.State == ShowingSettings {
+		g.showSettings()
+	} else if g.State == ShowingControls {
+		g.showControls()
+	} else if g.State == ShowingHighScores {
+		g.showHighScores()
+	} else if g.inMenu() {
+		g.menu()
+	} else {
+		g.run()
+		g.frozen = g.commands
+	}
+
+	g.drawBars(in.WindowW, in.WindowH)
+	g.audio.Music = g.music()
+	return g.commands
+}
+
+func (g *Game) backToMenu() {
+	g.runState = runState{}
+	g.State = FadingInMenu
+	g.fade = 1.1
+	g.menuSelection = -1
+}
+
+const (
+	menuStart = iota
+	menuDifficulty
+	menuHighScores
+	menuSettings
+	menuItemCount
+)
+
+func (g *Game) menu() {
+	mustStart := false
+	open := State(-1)
+	changeDifficulty := 0
+
+	mouseX, mouseY := g.in.MouseX, g.in.MouseY
+	mouseMoved := mouseX != g.lastMouseX || mouseY != g.lastMouseY
+	g.lastMouseX, g.lastMouseY = mouseX, mouseY
+	selection := g.menuSelection
+
+	if g.in.Up {
+		g.menuSelection = (max(0, g.menuSelection) + menuItemCount - 1) % menuItemCount
+	}
+	if g.in.Down {
+		g.menuSelection = (g.menuSelection + 1) % menuItemCount
+	}
+
+	startW, startH := g.size("start_button")
+	scale := g.canvas.h / 100
+	startW *= scale
+	startH *= scale
+	startX := (g.canvas.w - startW) / 2
+	startY := (g.canvas.h - startH) / 2
+	if startX <= mouseX && mouseX < startX+startW &&
+		startY <= mouseY && mouseY < startY+startH {
+		if mouseMoved {
+			g.menuSelection = menuStart
+		}
+		if g.in.Clicked {
+			mustStart = true
+		}
+	}
+	startTint := RGB(0.5, 0.5, 0.5)
+	if g.menuSelection == menuStart {
+		startTint = White
+	}
+	g.image("start_button", startX, startY, scale, startTint)
+
+	// The other menu items are texts below the start button. The difficulty
+	// changes when activated, the others open the screen with the state of
+	// the same index.
+	textItems := []string{
+		menuDifficulty: "DIFFICULTY: " + difficultyText(g.settings().Difficulty),
+		menuHighScores: "HIGH SCORES",
+		menuSettings:   "SETTINGS",
+	}
+	screens := []State{menuHighScores: ShowingHighScores, menuSettings: ShowingSettings}
+	textScale := g.fontScale(float64(scale) / 2)
+	y := float64(startY + startH + scale*4)
+	for i := menuStart + 1; i < menuItemCount; i++ {
+		w, h := g.bitmapTextSize(textItems[i], textScale)
+		x := (float64(g.canvas.w) - w) / 2
+		mx, my := float64(mouseX), float64(mouseY)
+		if x <= mx && mx < x+w && y <= my && my < y+h {
+			if mouseMoved {
+				g.menuSelection = i
+			}
+			if g.in.Clicked && i == menuDifficulty {
+				changeDifficulty = 1
+			} else if g.in.Clicked {
+				open = screens[i]
+			}
+		}
+		color := RGB(0.5, 0.5, 0.5)
+		if g.menuSelection == i {
+			color = White
+		}
+		g.centerText(textItems[i], y, textScale, color)
+		y += h * 3 / 2
+	}
+
+	g.image("cursor", mouseX-4, mouseY, scale)
+
+	if g.menuSelection != selection {
+		g.play(SoundMenuMove)
+	}
+
+	if g.in.Back && g.State == FadingInMenu {
+		g.Quit = true
+	}
+
+	if g.menuSelection == menuDifficulty {
+		if g.in.Left {
+			changeDifficulty = -1
+		}
+		if g.in.Right {
+			changeDifficulty = 1
+		}
+	}
+
+	if g.in.Confirm {
+		if g.menuSelection == menuDifficulty {
+			changeDifficulty = 1
+		} else if g.menuSelection > menuStart {
+			open = screens[g.menuSelection]
+		} else {
+			mustStart = true
+		}
+	}
+
+	if changeDifficulty != 0 && g.State == FadingInMenu {
+		s := g.settings()
+		s.Difficulty = nextDifficulty(s.Difficulty, changeDifficulty)
+		g.play(SoundMenuMove)
+		g.saveSettings()
+	}
+
+	if open != -1 && g.State == FadingInMenu {
+		g.play(SoundMenuSelect)
+		if open == ShowingSettings {
+			g.openSettings()
+		} else if open == ShowingHighScores {
+			g.openHighScores()
+		} else {
+			g.State = open
+		}
+		return
+	}
+
+	if mustStart && g.State != FadingOutMenu {
+		g.play(SoundMenuSelect)
+		g.State = FadingOutMenu
+	}
+
+	if g.State == FadingInMenu {
+		g.fade = max(0, g.fade-0.01)
+	} else if g.State == FadingOutMenu {
+		g.fade += 0.01
+		if g.fade >= 1 {
+			g.startRun()
+		}
+	}
+	a := max(0, min(1, g.fade))
+	g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, a))
+}
+
+func (g *Game) startRun() {
+	g.Runs++
+	g.Seed = g.newSeed()
+	g.Difficulty = g.settings().Difficulty
+	g.State = FadingInGame
+	g.fade = 1.4
+	g.cam.zoom = g.tuning().Intro.Zoom
+	g.cam.x = 100
+	g.cam.y = 300
+}
+
+// retry starts a new run, skipping the intro up to where the bike comes in.
+func (g *Game) retry() {
+	g.Runs++
+	g.runState = runState{}
+	g.Seed = g.newSeed()
+	g.Difficulty = g.settings().Difficulty
+	g.cam.zoom = playZoom
+	bikeW, _ := g.size("bike_0")
+	g.BikeX = float64(-3 * bikeW)
+	g.BikeY = 24
+	g.BikeSpeed = 0.5
+	g.State = BikeComingIn
+}
+
+func (g *Game) newSeed() int64 {
+	if g.NewSeed == nil {
+		return 0
+	}
+	return g.NewSeed()
+}
+
+func (g *Game) run() {
+	g.cam.screenW, g.cam.screenH = g.canvas.w, g.canvas.h
+	g.cam.clamp()
+	g.cam.update(g.tuning().Camera.ShakeDecay)
+
+	visibleLeft := max(0, round(g.cam.x-0.51))
+	visibleWidth := round(float64(g.canvas.w)/g.cam.zoom+0.51) + 1
+	visibleRight := visibleLeft + visibleWidth - 1
+
+	visibleBottom := max(0, round(g.cam.y-0.51))
+	visibleHeight := round(float64(g.canvas.h)/g.cam.zoom+0.51) + 1
+	visibleTop := visibleBottom + visibleHeight - 1
+	_ = visibleTop
+
+	streetW, streetH := g.size("street")
+	fenceW, fenceH := g.size("fence")
+	bikeW, _ := g.size("bike_0")
+	carClip := g.clip(g.pursuer().clip())
+	carW, _ := g.size(carClip.Frames[0])
+	keysW, _ := g.size("press_left")
+	frontYardH := fenceH + 1
+	lampDx := streetW + 30
+
+	// Draw the world.
+
+	city := g.city()
+	day := g.daytime(float64(visibleLeft + visibleWidth/2))
+	weatherNow := g.weather(city)
+
+	_, skyY := g.worldToScreen(0, 300)
+	g.rectTint(0, skyY, g.canvas.w, float64(g.canvas.h)-skyY, [4]Color{
+		day.skyTop,
+		day.skyTop,
+		day.skyBottom,
+		day.skyBottom,
+	})
+	g.rect(0, 0, g.canvas.w, skyY, day.skyTop)
+
+	g.drawParallax(city, streetH, visibleWidth, day)
+
+	g.fillRect(visibleLeft, streetH, visibleWidth, frontYardH, frontYardColor)
+
+	gapDx := lotW
+	gapI := visibleLeft / gapDx
+	gapX := gapI * gapDx
+	for gapX < visibleRight+gapDx {
+		if l := city.lot(gapI); l.water {
+			g.drawWater(gapX, streetH)
+		} else if l.park {
+			g.fillRect(gapX, streetH, gapDx, 130, rgb(38, 56, 34))
+			g.draw("grass", gapX+10, streetH+19)
+			g.draw("grass", gapX+30, streetH+40)
+			g.draw("grass", gapX+20, streetH+53)
+			g.draw("grass", gapX+45, streetH+61)
+			g.draw("grass", gapX+5, streetH+74)
+			g.draw("grass", gapX+37, streetH+87)
+			g.draw("grass", gapX+30, streetH+110)
+			for _, tree := range l.trees {
+				g.draw(tree.imageName, gapX+tree.dx, streetH+tree.dy)
+			}
+		}
+
+		gapI++
+		gapX += gapDx
+	}
+
+	buildingDx := lotW
+	buildingI := visibleLeft / buildingDx
+	buildingX := buildingI * buildingDx
+	for buildingX < visibleRight+buildingDx {
+		if l := city.lot(buildingI); l.building != "" {
+			g.draw(l.building, buildingX, streetH, day.lit(l.tint))
+
+			for _, item := range l.props {
+				g.draw(item.imageName, buildingX+item.dx, streetH+item.dy)
+			}
+		}
+
+		buildingI++
+		buildingX += buildingDx
+	}
+
+	g.drawFog(weatherNow)
+
+	topFenceI := visibleLeft / fenceW
+	topFenceX := topFenceI * fenceW
+	for topFenceX < visibleRight {
+		img := city.fenceDoor(topFenceI)
+		g.draw(img, topFenceX, streetH)
+		topFenceI++
+		topFenceX += fenceW
+	}
+
+	streetX := visibleLeft / streetW * streetW
+	for streetX < visibleRight {
+		g.draw("street", streetX, 0)
+		streetX += streetW
+	}
+
+	bottomFenceX := visibleLeft / fenceW * fenceW
+	for bottomFenceX < visibleRight {
+		g.draw("fence", bottomFenceX, 0)
+		bottomFenceX += fenceW
+	}
+
+	lampOffsetX := -15
+	topLampX := visibleLeft/lampDx*lampDx + lampOffsetX
+	for topLampX < visibleRight {
+		g.draw("lamp_top", topLampX, 26)
+		g.draw("lamp_top_glow", topLampX, 26, RGBA(1, 1, 1, day.lampGlow))
+		topLampX += lampDx
+	}
+
+	if g.State == BikeComingIn {
+		g.BikeX += g.BikeSpeed
+
+		clip := "bike"
+		x := round(g.BikeX) + bikeW/2
+		cx := visibleLeft + visibleWidth/2
+		if cx-20 <= x && x <= cx+20 {
+			clip = "bike_back"
+		}
+		g.bikeAnim.Switch(g.clip(clip))
+		if g.bikeAnim.Update(g.BikeSpeed) {
+			g.play(SoundPedal)
+		}
+
+		if x > cx+20 {
+			g.BikeSpeed = min(1, g.BikeSpeed+0.007)
+		}
+
+		if x > visibleRight {
+			g.State = CarComingIn
+			g.CarX = float64(visibleLeft - 2*carW)
+			g.CarY = 21
+		}
+
+		g.draw(g.bikeAnim.Frame(), g.BikeX, g.BikeY)
+	}
+
+	if g.State == CarComingIn {
+		g.CarX += 1.5
+		g.audio.Engine = 1.5
+		g.carAnim.Switch(carClip)
+		g.carAnim.Update(1)
+		g.draw(g.carAnim.Frame(), g.CarX, g.CarY)
+
+		if round(g.CarX) > visibleRight+carW {
+			g.State = Playing
+			g.BikeX = float64(visibleRight + 140)
+			g.BikeSpeed = 0.9
+			g.CarX = float64(visibleRight + 10)
+			g.arrowHintTimer = 600
+			g.CarSpeed = 0.75
+			g.obstaclesFrom = g.BikeX + obstaclesAhead
+		}
+	}
+
+	if g.State == Playing || g.State == EnteringName || g.State == GameOver {
+		bike := g.tuning().Bike
+		g.BikeSpeed *= bike.Decay
+
+		left := g.in.PedalLeft
+		right := g.in.PedalRight
+
+		if g.nextKeyLeft && left || !g.nextKeyLeft && right {
+			g.BikeSpeed *= g.pedalStroke()
+			g.nextKeyLeft = !g.nextKeyLeft
+		} else if g.nextKeyLeft && right || !g.nextKeyLeft && left {
+			// Punish the wrong key.
+			g.BikeSpeed *= bike.WrongKeyDecay
+		}
+
+		g.BikeSpeed = min(g.maxBikeSpeed(), max(bike.MinSpeed, g.BikeSpeed))
+		if !g.Dead {
+			g.TopSpeed = max(g.TopSpeed, g.BikeSpeed)
+			g.FramesAlive++
+			g.updatePickups()
+			g.updateStamina()
+		}
+
+		g.pursue(carW)
+		g.changeLanes()
+		g.steerCar(carW)
+
+		g.BikeX += g.BikeSpeed
+		g.CarX += g.CarSpeed * g.carSpeedFactor()
+		g.audio.Engine = g.CarSpeed
+		g.jump()
+
+		camera := g.tuning().Camera
+		g.cam.follow(g.BikeX-float64(bikeW)/2+camera.LookAhead*g.BikeSpeed, camera.Follow)
+
+		g.bikeAnim.Switch(g.clip("bike"))
+		if g.bikeAnim.Update(g.BikeSpeed) && !g.Dead {
+			g.play(SoundPedal)
+		}
+
+		g.carAnim.Switch(carClip)
+		g.carAnim.Update(1)
+
+		g.drawObstacles(city, visibleLeft, visibleRight)
+		g.drawPickups(city, visibleLeft, visibleRight)
+
+		if g.Dead {
+			// The run ends when the crash animation is over.
+			g.deathAnim.Update(1)
+			if !g.deathAnim.Done() {
+				name := g.deathAnim.Frame()
+				if g.crashX != 0 {
+					g.addSprite(name, g.crashX-6, g.BikeY, g.BikeY)
+				} else {
+					g.addSprite(name, g.CarX+g.pursuer().crashDx(), g.CarY, g.CarY+carLaneOffset)
+				}
+			} else {
+				g.CarSpeed = min(50, g.CarSpeed*1.01)
+			}
+		} else {
+			g.addSprite(g.bikeAnim.Frame(), g.BikeX, g.BikeY+g.jumpZ, g.BikeY)
+		}
+		g.addSprite(g.carAnim.Frame(), g.CarX, g.CarY, g.CarY+carLaneOffset)
+		g.drawSprites()
+		g.drawWeather(weatherNow)
+
+		g.arrowHintTimer = max(0, g.arrowHintTimer-1)
+		if g.arrowHintTimer > 0 {
+			arrowImage := "press_left"
+			if g.arrowHintTimer/15%2 == 0 {
+				arrowImage = "press_right"
+			}
+
+			tint := White
+			if g.arrowHintTimer < 100 {
+				a := float32(g.arrowHintTimer) / 100
+				tint = RGBA(1, 1, 1, a)
+			}
+
+			g.draw(arrowImage, g.BikeX+float64(bikeW-keysW)/2, 70, tint)
+		}
+
+		if !g.Dead {
+			g.Miles += g.BikeSpeed * g.tuning().MilesPerPixel
+			g.updateMilestones()
+		}
+
+		miles := fmt.Sprintf("%.3f Miles", g.Miles)
+		g.bitmapText(miles, float64(g.canvas.w)/2, 5, textStyle{scale: 1, color: milesColor, align: alignCenter})
+		g.drawActivePickups()
+		g.drawStamina()
+		g.drawBanner(city)
+
+		caught := g.BikeX < g.CarX+float64(carW) && g.CarX < g.BikeX+float64(bikeW) &&
+			sameLane(g.BikeY, g.CarY+carLaneOffset)
+		if !g.Dead && caught && !g.useShield(carW) {
+			g.Dead = true
+			g.deathAnim.Play(g.clip("death"), g.endRun)
+			g.play(SoundCrash)
+			g.cam.shakeBy(g.tuning().Camera.CrashShake)
+		}
+	}
+
+	bottomLampX := visibleLeft/lampDx*lampDx + lampOffsetX
+	for bottomLampX < visibleRight {
+		g.draw("lamp_bottom", bottomLampX+16, 7)
+		g.draw("lamp_bottom_glow", bottomLampX+16, 7, RGBA(1, 1, 1, day.lampGlow))
+		bottomLampX += lampDx
+	}
+
+	if g.State == FadingInGame {
+		g.fade -= 0.01
+		a := max(0, min(1, g.fade))
+		g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0, 0, 0, a))
+		if g.fade < -0.3 {
+			g.State = AscendingIntoGame
+		}
+	}
+
+	if g.State == AscendingIntoGame {
+		if g.cam.descend() {
+			g.State = ZoomingIntoGame
+		}
+	}
+
+	if g.State == ZoomingIntoGame {
+		g.zoomTimer++
+		intro := g.tuning().Intro
+		t := float64(g.zoomTimer) * intro.ZoomSpeed
+		zoom := intro.Zoom + ease.InOutQuad(t)*(playZoom-intro.Zoom)
+		if t >= 1 {
+			zoom = playZoom
+		}
+		// Zoom into the middle of the bottom of the screen, where the street
+		// is.
+		g.cam.zoomAt(zoom, float64(g.canvas.w)/2, float64(g.canvas.h))
+		if t >= 1 {
+			g.BikeX = float64(visibleLeft - 3*bikeW)
+			g.BikeY = 24
+			g.BikeSpeed = 0.5
+			g.State = BikeComingIn
+		}
+	}
+
+	if g.State == EnteringName {
+		g.enterName()
+	}
+
+	if g.State == GameOver {
+		g.gameOver()
+	}
+}
+
+func (g *Game) size(imageName string) (int, int) {
+	return g.assets.ImageSize(imageName)
+}
+
+// clip returns the animation with the given name.
+func (g *Game) clip(name string) *anim.Clip {
+	c, ok := g.assets.Animations()[name]
+	if !ok {
+		panic("missing animation " + name)
+	}
+	return c
+}
+
+// blend moves a towards b by the given part of the way.
+func blend(a, b, part float64) float64 {
+	return (1-part)*a + part*b
+}
+
+func round(x float64) int {
+	if x < 0 {
+		return int(x - 0.5)
+	}
+	return int(x + 0.5)
+}
+
+func toFloat64(x any) float64 {
+	switch x := x.(type) {
+	case int:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case float64:
+		return float64(x)
+	case int8:
+		return float64(x)
+	case int16:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint8:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case complex64:
+		return float64(real(x))
+	case complex128:
+		return float64(real(x))
+	}
+	return 0
+}
@@ -0,0 +1,283 @@
+package sim
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"city_bike/anim"
+	"city_bike/atlas"
+	"city_bike/font"
+	"city_bike/highscore"
+	"city_bike/replay"
+)
+
+// testAssets are the game's assets from the rsc folder, without a window.
+type testAssets struct {
+	atlas      *atlas.Atlas
+	font       *font.Font
+	animations anim.Clips
+}
+
+func loadTestAssets(t testing.TB) *testAssets {
+	t.Helper()
+	rsc := os.DirFS("../rsc")
+	var a testAssets
+	var err error
+	if a.atlas, err = atlas.Load(rsc, "atlas"); err != nil {
+		t.Fatal(err)
+	}
+	if a.font, err = font.Load(rsc, "font"); err != nil {
+		t.Fatal(err)
+	}
+	if a.animations, err = anim.Load(rsc, "animations"); err != nil {
+		t.Fatal(err)
+	}
+	return &a
+}
+
+func (a *testAssets) ImageSize(name string) (int, int) {
+	f, ok := a.atlas.Frame(name)
+	if !ok {
+		panic("missing image " + name)
+	}
+	return f.W, f.H
+}
+
+func (a *testAssets) Font() *font.Font {
+	return a.font
+}
+
+func (a *testAssets) Animations() anim.Clips {
+	return a.animations
+}
+
+const (
+	testSeed    = 42
+	testWindowW = 1280
+	testWindowH = 720
+)
+
+// pedal is a player who pedals left and right every few frames and does
+// nothing else.
+func pedal(frame, every int) Input {
+	in := Input{WindowW: testWindowW, WindowH: testWindowH}
+	in.PedalLeft = frame%(2*every) == 0
+	in.PedalRight = frame%(2*every) == every
+	return in
+}
+
+// play runs the game until the run is over or maxFrames are played. It returns
+// the frame in which the bike crashed, or -1.
+func play(g *Game, maxFrames int, input func(frame int) Input) int {
+	crashedAt := -1
+	for frame := range maxFrames {
+		g.Step(input(frame))
+		if crashedAt == -1 && g.Dead {
+			crashedAt = frame
+		}
+		if !g.Running() {
+			break
+		}
+	}
+	return crashedAt
+}
+
+func TestRunEndsInCrash(t *testing.T) {
+	g := NewRun(loadTestAssets(t), testSeed, "normal")
+	crashedAt := play(g, 60*60*10, func(frame int) Input { return pedal(frame, 7) })
+
+	if crashedAt == -1 {
+		t.Fatal("the bike never crashed")
+	}
+	if g.State != GameOver {
+		t.Errorf("want state %v after the crash but have %v", GameOver, g.State)
+	}
+	if g.Miles <= 0 {
+		t.Errorf("the bike did not get anywhere, it rode %v miles", g.Miles)
+	}
+	if g.FramesAlive == 0 || g.TopSpeed <= 0 {
+		t.Errorf("no results recorded: %v frames alive, top speed %v", g.FramesAlive, g.TopSpeed)
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	assets := loadTestAssets(t)
+	run := func() (float64, int) {
+		g := NewRun(assets, testSeed, "hard")
+		crashedAt := play(g, 60*60*10, func(frame int) Input { return pedal(frame, 6) })
+		return g.Miles, crashedAt
+	}
+	miles1, crash1 := run()
+	miles2, crash2 := run()
+	if miles1 != miles2 || crash1 != crash2 {
+		t.Errorf(
+			"the same input gave different runs: %v miles crashing at %d, then %v miles crashing at %d",
+			miles1, crash1, miles2, crash2,
+		)
+	}
+}
+
+func TestReplayReproducesRun(t *testing.T) {
+	assets := loadTestAssets(t)
+
+	g := NewRun(assets, testSeed, "normal")
+	rec := replay.New(testWindowW, testWindowH, false, 0, g.Seed, g.Difficulty)
+	rec.CaughtAt = play(g, 60*60*10, func(frame int) Input {
+		in := pedal(frame, 8)
+		// Jump and change lanes now and then.
+		in.Jump = frame%200 == 100
+		in.LaneUp = frame%500 == 250
+		in.LaneDown = frame%500 == 450
+		rec.Frames = append(rec.Frames, toFrame(in))
+		return in
+	})
+	rec.Miles = g.Miles
+
+	var buf bytes.Buffer
+	if err := rec.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := replay.Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyReplay(t, assets, loaded)
+}
+
+// toFrame returns the recorded keys of the input.
+func toFrame(in Input) replay.Frame {
+	var f replay.Frame
+	for _, k := range []struct {
+		pressed bool
+		key     replay.Frame
+	}{
+		{in.PedalLeft, replay.PedalLeft},
+		{in.PedalRight, replay.PedalRight},
+		{in.Jump, replay.Jump},
+		{in.LaneUp, replay.LaneUp},
+		{in.LaneDown, replay.LaneDown},
+	} {
+		if k.pressed {
+			f |= k.key
+		}
+	}
+	return f
+}
+
+// verifyReplay plays the recording and checks its result.
+func verifyReplay(t *testing.T, assets Assets, rec *replay.Recording) {
+	t.Helper()
+	g := NewRun(assets, rec.Seed, rec.Difficulty)
+	caughtAt := play(g, len(rec.Frames), func(frame int) Input {
+		f := rec.Frames[frame]
+		return Input{
+			WindowW:    rec.WindowW,
+			WindowH:    rec.WindowH,
+			PedalLeft:  f.Has(replay.PedalLeft),
+			PedalRight: f.Has(replay.PedalRight),
+			Jump:       f.Has(replay.Jump),
+			LaneUp:     f.Has(replay.LaneUp),
+			LaneDown:   f.Has(replay.LaneDown),
+		}
+	})
+	if err := rec.Verify(g.Miles, caughtAt); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestRecordingOfRestartedRun records a run like the game does with -record,
+// only the frames that advance the run, until the player restarts it from the
+// pause menu.
+func TestRecordingOfRestartedRun(t *testing.T) {
+	assets := loadTestAssets(t)
+	g := NewRun(assets, testSeed, "normal")
+	rec := replay.New(testWindowW, testWindowH, false, 0, g.Seed, g.Difficulty)
+
+	// The player pauses a while after the intro, then picks RESTART.
+	const pauseAt = 2000
+	script := map[int]func(*Input){
+		pauseAt:     func(in *Input) { in.Pause = true },
+		pauseAt + 1: func(in *Input) { in.Down = true },
+		pauseAt + 2: func(in *Input) { in.Confirm = true },
+	}
+	run := g.Runs
+	for frame := 0; g.Runs == run; frame++ {
+		if frame > pauseAt+10 {
+			t.Fatal("the run was not restarted")
+		}
+		in := pedal(frame, 8)
+		if change, ok := script[frame]; ok {
+			in = Input{WindowW: testWindowW, WindowH: testWindowH}
+			change(&in)
+		}
+		running := g.Running()
+		g.Step(in)
+		if running && !g.Paused() && g.Runs == run {
+			rec.Frames = append(rec.Frames, toFrame(in))
+			rec.Miles = g.Miles
+		}
+	}
+
+	if rec.Miles == 0 {
+		t.Fatal("the recording has no distance")
+	}
+	if len(rec.Frames) != pauseAt {
+		t.Errorf("%d frames were recorded, want the %d before the pause", len(rec.Frames), pauseAt)
+	}
+	verifyReplay(t, assets, rec)
+}
+
+func BenchmarkStep(b *testing.B) {
+	g := NewRun(loadTestAssets(b), testSeed, "easy")
+	frame := 0
+	for b.Loop() {
+		g.Step(pedal(frame, 5))
+		frame++
+		if !g.Running() {
+			g = NewRun(g.assets, testSeed, "easy")
+			frame = 0
+		}
+	}
+}
+
+func TestSkipNameEntry(t *testing.T) {
+	g := New(loadTestAssets(t))
+	g.HighScores = &highscore.Table{}
+	g.Miles = 1
+	g.endRun()
+	if g.State != EnteringName {
+		t.Fatalf("want state %v for a new high score but have %v", EnteringName, g.State)
+	}
+
+	g.Step(Input{WindowW: testWindowW, WindowH: testWindowH, Characters: "abc"})
+	g.Step(Input{WindowW: testWindowW, WindowH: testWindowH, Back: true})
+
+	if g.State != GameOver {
+		t.Errorf("want state %v after going back but have %v", GameOver, g.State)
+	}
+	if entries := g.HighScores.Ranking(g.Difficulty); len(entries) != 0 {
+		t.Errorf("no entry should be added but have %v", entries)
+	}
+}
+
+func TestHighScoresReturnToMenuWithoutFade(t *testing.T) {
+	g := New(loadTestAssets(t))
+	in := Input{WindowW: testWindowW, WindowH: testWindowH}
+	for g.fade > 0 {
+		g.Step(in)
+	}
+
+	g.openHighScores()
+	g.Step(in)
+	back := in
+	back.Back = true
+	g.Step(back)
+
+	if g.State != FadingInMenu {
+		t.Fatalf("want state %v after the high scores but have %v", FadingInMenu, g.State)
+	}
+	if g.fade != 0 {
+		t.Errorf("the menu fades in again from %v", g.fade)
+	}
+}
@@ -0,0 +1,56 @@
+package sim
+
+// The rider's stamina is kept as fatigue, from 0 for a fresh rider to 1 for an
+// exhausted one, so a new run starts out fresh.
+
+// pedalStroke tires the rider for a pedal stroke and returns the factor on the
+// bike's speed that the stroke gives.
+func (g *Game) pedalStroke() float64 {
+	t := g.tuning().Stamina
+	cadence := float64(t.RelaxedCadence) / float64(max(1, g.sinceStroke))
+	g.fatigue = min(1, g.fatigue+t.PedalCost*min(t.MaxCadenceFactor, max(1, cadence)))
+	g.sinceStroke = 0
+
+	// The wheels slip on a wet or snowy street.
+	gain := 1 + (1/g.tuning().Bike.PedalBoost-1)*g.grip()
+	if g.exhausted {
+		gain = 1 + (gain-1)*t.ExhaustedGain
+	}
+	if g.fatigue >= 1 {
+		g.exhausted = true
+	}
+	return gain
+}
+
+// updateStamina lets the rider recover, faster while coasting.
+func (g *Game) updateStamina() {
+	t := g.tuning().Stamina
+	g.sinceStroke++
+	regen := t.Regen
+	if g.sinceStroke > t.CoastDelay {
+		regen += t.CoastRegen
+	}
+	g.fatigue = max(0, g.fatigue-regen)
+	if g.exhausted && 1-g.fatigue >= t.RecoverAt {
+		g.exhausted = false
+	}
+}
+
+// drawStamina shows the rider's stamina as a bar in the top-right corner of the
+// screen. It blinks red while the rider is exhausted.
+func (g *Game) drawStamina() {
+	margin := 5
+	w := 20
+	h := 2
+	x := g.canvas.w - margin - w
+
+	color := White
+	if g.exhausted {
+		color = RGB(0.9, 0.2, 0.2)
+		if g.FramesAlive/15%2 == 0 {
+			color = RGBA(0.9, 0.2, 0.2, 0.5)
+		}
+	}
+	g.rect(x, margin, w, h, RGBA(1, 1, 1, 0.3))
+	g.rect(x, margin, float64(w)*(1-g.fatigue), h, color)
+}
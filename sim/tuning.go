@@ -0,0 +1,10 @@
+package sim
+
+import "city_bike/tuning"
+
+func (g *Game) tuning() *tuning.Tuning {
+	if g.Tuning == nil {
+		g.Tuning = tuning.Default()
+	}
+	return g.Tuning
+}
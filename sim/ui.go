@@ -0,0 +1,119 @@
+package sim
+
+import "strings"
+
+// menuList is a vertical list of text items, one of which is selected. The
+// selection can be changed with the up and down keys and by hovering with the
+// mouse.
+type menuList struct {
+	selection  int
+	lastMouseX int
+	lastMouseY int
+}
+
+// updateMenuList handles the input for the menu and draws its items centered
+// horizontally, starting at y. Items with a '\t' are rows of a table, see
+// tableRow. It returns the index of the item that was activated in this frame,
+// or -1 if none was.
+func (g *Game) updateMenuList(m *menuList, items []string, y, scale float64) int {
+	mouseX, mouseY := g.in.MouseX, g.in.MouseY
+	mouseMoved := mouseX != m.lastMouseX || mouseY != m.lastMouseY
+	m.lastMouseX, m.lastMouseY = mouseX, mouseY
+	selection := m.selection
+
+	if g.in.Up {
+		m.selection = (m.selection + len(items) - 1) % len(items)
+	}
+	if g.in.Down {
+		m.selection = (m.selection + 1) % len(items)
+	}
+
+	_, lineH := g.bitmapTextSize("A", scale)
+	tableW := g.tableWidth(items, scale)
+
+	activated := -1
+	for i, item := range items {
+		w, h := g.bitmapTextSize(item, scale)
+		if strings.Contains(item, "\t") {
+			w = tableW
+		}
+		x := (float64(g.canvas.w) - w) / 2
+		mx, my := float64(mouseX), float64(mouseY)
+		hovered := x <= mx && mx < x+w && y <= my && my < y+h
+		if hovered && mouseMoved {
+			m.selection = i
+		}
+		// A click selects the item as well, even if the mouse did not move
+		// onto it, so the menu acts on the item that was clicked.
+		if hovered && g.in.Clicked {
+			m.selection = i
+			activated = i
+		}
+
+		color := RGB(0.5, 0.5, 0.5)
+		if i == m.selection {
+			color = White
+		}
+		g.tableRow(item, y, tableW, scale, color)
+		y += lineH * 3 / 2
+	}
+
+	if g.in.Confirm {
+		activated = m.selection
+	}
+
+	if activated != -1 {
+		g.play(SoundMenuSelect)
+	} else if m.selection != selection {
+		g.play(SoundMenuMove)
+	}
+
+	scale = float64(g.canvas.h / 100)
+	g.image("cursor", mouseX-4, mouseY, scale)
+
+	return activated
+}
+
+// centerText draws a text in the pixel font, centered horizontally. y is the
+// top of the text.
+func (g *Game) centerText(text string, y, scale float64, c Color) {
+	g.bitmapText(text, float64(g.canvas.w)/2, y, textStyle{scale: scale, color: c, align: alignCenter})
+}
+
+// tableGap is the least space between the label and the value in a row of a
+// table, in pixels of the font.
+const tableGap = 8
+
+// tableWidth returns the width of a table with the given rows, the widest
+// label and the widest value with the gap between them. Texts without a '\t'
+// are not rows and do not count.
+func (g *Game) tableWidth(rows []string, scale float64) float64 {
+	labelW, valueW := 0.0, 0.0
+	for _, row := range rows {
+		label, value, ok := strings.Cut(row, "\t")
+		if !ok {
+			continue
+		}
+		w, _ := g.bitmapTextSize(label, scale)
+		labelW = max(labelW, w)
+		w, _ = g.bitmapTextSize(value, scale)
+		valueW = max(valueW, w)
+	}
+	return labelW + tableGap*scale + valueW
+}
+
+// tableRow draws a row of a table that is w wide and centered horizontally.
+// The pixel font is not monospaced, so instead of padding them with spaces
+// the row is a label and a value separated by '\t'. The label is aligned
+// left and the value right, which lines up the numbers. Texts without a '\t'
+// are centered.
+func (g *Game) tableRow(row string, y, w, scale float64, c Color) {
+	label, value, ok := strings.Cut(row, "\t")
+	if !ok {
+		g.centerText(row, y, scale, c)
+		return
+	}
+	left := (float64(g.canvas.w) - w) / 2
+	g.bitmapText(label, left, y, textStyle{scale: scale, color: c})
+	g.bitmapText(value, left+w, y, textStyle{scale: scale, color: c, align: alignRight})
+}
@@ -0,0 +1,127 @@
+package sim
+
+import "math"
+
+// The weather changes along the street. Every part of the street of
+// tuning.Weather.Length miles has its own weather, which fades in and out at
+// the ends of the part.
+
+type weatherKind int
+
+const (
+	weatherClear weatherKind = iota
+	weatherRain
+	weatherFog
+	weatherSnow
+)
+
+// weatherChances are how likely the kinds of weather are, out of their sum.
+var weatherChances = []int{
+	weatherClear: 5,
+	weatherRain:  2,
+	weatherFog:   2,
+	weatherSnow:  1,
+}
+
+type weather struct {
+	kind weatherKind
+	// intensity goes from 0 for clear to 1 for the full effect.
+	intensity float64
+}
+
+// weatherFade is the part at both ends of a weather part in which the weather
+// fades in or out.
+const weatherFade = 0.15
+
+// weather returns the weather at the world position x. The start of the
+// street is always clear.
+func (w *world) weather(x, length float64) weather {
+	i := int(math.Floor(x / length))
+	if i <= 0 {
+		return weather{}
+	}
+
+	r := w.rand(layerWeather, i)
+	total := 0
+	for _, c := range weatherChances {
+		total += c
+	}
+	n := r.Intn(total)
+	kind := weatherClear
+	for n >= weatherChances[kind] {
+		n -= weatherChances[kind]
+		kind++
+	}
+
+	t := x/length - float64(i)
+	return weather{
+		kind:      kind,
+		intensity: min(1, t/weatherFade, (1-t)/weatherFade),
+	}
+}
+
+// weather returns the weather where the bike is.
+func (g *Game) weather(city *world) weather {
+	length := g.tuning().Weather.Length / g.tuning().MilesPerPixel
+	return city.weather(g.BikeX, length)
+}
+
+// grip is the factor on the speed that the bike gains per pedal stroke, it is
+// less than 1 on wet or snowy streets.
+func (g *Game) grip() float64 {
+	t := g.tuning().Weather
+	w := g.weather(g.city())
+	full := 1.0
+	switch w.kind {
+	case weatherRain:
+		full = t.RainGrip
+	case weatherFog:
+		full = t.FogGrip
+	case weatherSnow:
+		full = t.SnowGrip
+	}
+	return 1 - (1-full)*w.intensity
+}
+
+// drawFog covers what is behind the street with fog, so that the distance
+// disappears in it.
+func (g *Game) drawFog(w weather) {
+	if w.kind == weatherFog {
+		g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0.55, 0.57, 0.6, float32(0.5*w.intensity)))
+	}
+}
+
+// drawWeather draws the rain or snow in front of everything and a light fog
+// over the street.
+func (g *Game) drawWeather(w weather) {
+	g.weatherFrame++
+	frame := float64(g.weatherFrame)
+	width, height := float64(g.canvas.w), float64(g.canvas.h)
+
+	switch w.kind {
+	case weatherRain:
+		n := round(300 * w.intensity)
+		speed := height / 40
+		dropW := max(g.canvas.pixel(), 0.25)
+		dropH := 3.0
+		for i := range n {
+			h := mix(uint64(i))
+			x := math.Mod(float64(h%10000)/10000*width-frame*speed/8, width)
+			y := math.Mod(float64(h>>20%10000)/10000*height+frame*speed, height)
+			g.rect(math.Mod(x+width, width), y-dropH, dropW, dropH, RGBA(0.6, 0.7, 0.9, 0.5))
+		}
+	case weatherSnow:
+		n := round(200 * w.intensity)
+		speed := height / 400
+		size := max(g.canvas.pixel(), 0.5)
+		for i := range n {
+			h := mix(uint64(i))
+			sway := 2 * math.Sin(frame/40+float64(h%100))
+			x := math.Mod(float64(h%10000)/10000*width+sway, width)
+			y := math.Mod(float64(h>>20%10000)/10000*height+frame*speed*(1+float64(h>>40%3)/2), height)
+			g.rect(math.Mod(x+width, width), y, size, size, RGBA(1, 1, 1, 0.8))
+		}
+	case weatherFog:
+		g.rect(0, 0, g.canvas.w, g.canvas.h, RGBA(0.55, 0.57, 0.6, float32(0.2*w.intensity)))
+	}
+}
@@ -0,0 +1,231 @@
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// The city is generated from the seed of the run. Everything in it depends
+// only on the seed and its position, so whatever scrolls out of view and comes
+// back looks the same as before.
+
+// DailySeed returns a city seed that is the same for everybody on the same day
+// (in UTC), so players can compete in the same city.
+func DailySeed(t time.Time) int64 {
+	y, m, d := t.UTC().Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// The layers of the city have their own random numbers so they do not depend
+// on each other.
+const (
+	layerLots = iota
+	layerFence
+	layerObstacles
+	layerPickups
+	layerWeather
+	// layerParallax is the first of the parallax layers, the others follow
+	// it.
+	layerParallax
+)
+
+// lotsPerChunk is the number of lots that are generated at a time.
+const lotsPerChunk = 16
+
+// lotW is the width of a lot. Buildings are one pixel wider so that their
+// walls overlap.
+const lotW = 50
+
+// maxCachedChunks is the number of chunks that are kept after they were
+// generated. They are cheap to generate again when they come back into view.
+const maxCachedChunks = 8
+
+// world generates the city for a seed.
+type world struct {
+	seed int64
+	// zoneLength is the length of a zone in pixels.
+	zoneLength int
+	chunks     map[int]*chunk
+}
+
+// chunk is a row of lots behind the front yard.
+type chunk struct {
+	lots [lotsPerChunk]lot
+}
+
+// lot is the space for one building, or part of a park or river.
+type lot struct {
+	park  bool
+	water bool
+	// trees are drawn in parks.
+	trees []drawItem
+	// building is the image of the building if this is not a park.
+	building string
+	tint     Color
+	// props are the bushes and trash cans in front of the building.
+	props []drawItem
+}
+
+type drawItem struct {
+	imageName string
+	dx        int
+	dy        int
+}
+
+// city returns the generator for the seed of the current run.
+func (g *Game) city() *world {
+	zoneLength := g.zoneLength()
+	if g.world.chunks == nil || g.world.seed != g.Seed || g.world.zoneLength != zoneLength {
+		g.world = world{
+			seed:       g.Seed,
+			zoneLength: zoneLength,
+			chunks:     make(map[int]*chunk),
+		}
+	}
+	return &g.world
+}
+
+// rand returns random numbers for the thing at index i in the given layer.
+func (w *world) rand(layer, i int) *rand.Rand {
+	h := uint64(w.seed)
+	h = mix(h ^ uint64(layer))
+	h = mix(h ^ uint64(i))
+	s := splitMix(h)
+	return rand.New(&s)
+}
+
+// splitMix is the random source of the world. The world needs a new source
+// for every thing that it generates, which is much cheaper with SplitMix64
+// than with the default source of package rand.
+type splitMix uint64
+
+func (s *splitMix) Uint64() uint64 {
+	*s += 0x9E3779B97F4A7C15
+	return mix(uint64(*s))
+}
+
+func (s *splitMix) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *splitMix) Seed(seed int64) {
+	*s = splitMix(seed)
+}
+
+// mix is the finalizer of SplitMix64. It spreads every bit of x over all bits
+// of the result so that neighboring indices get unrelated random numbers.
+func mix(x uint64) uint64 {
+	x += 0x9E3779B97F4A7C15
+	x = (x ^ x>>30) * 0xBF58476D1CE4E5B9
+	x = (x ^ x>>27) * 0x94D049BB133111EB
+	return x ^ x>>31
+}
+
+func (w *world) lot(i int) *lot {
+	chunkIndex := floorDiv(i, lotsPerChunk)
+	c, ok := w.chunks[chunkIndex]
+	if !ok {
+		if len(w.chunks) >= maxCachedChunks {
+			clear(w.chunks)
+		}
+		c = w.generateChunk(chunkIndex)
+		w.chunks[chunkIndex] = c
+	}
+	return &c.lots[i-chunkIndex*lotsPerChunk]
+}
+
+func (w *world) generateChunk(index int) *chunk {
+	r := w.rand(layerLots, index)
+	c := &chunk{}
+	lastBuilding := -1
+	for i := range c.lots {
+		l := &c.lots[i]
+		z := &zones[w.zoneAt((index*lotsPerChunk+i)*lotW)]
+
+		if z.water {
+			l.water = true
+			continue
+		}
+
+		// Parks are one or two lots wide, except in zones that are all park.
+		wasPark := i > 0 && c.lots[i-1].park
+		if len(z.buildings) == 0 ||
+			wasPark && r.Intn(3) == 0 ||
+			!wasPark && z.parkChance > 0 && r.Intn(z.parkChance) == 0 {
+			l.park = true
+			l.trees = randTrees(r)
+			continue
+		}
+
+		n := len(z.buildings)
+		kind := r.Intn(n)
+		if kind == lastBuilding && n > 1 {
+			kind = (kind + 1 + r.Intn(n-1)) % n
+		}
+		lastBuilding = kind
+		l.building = z.buildings[kind]
+
+		v := 0.45 + 0.18*r.Float32()
+		hue := 0.04 * (r.Float32()*2 - 1)
+		l.tint = RGB(z.tint.R*v*(1+hue), z.tint.G*v, z.tint.B*v*(1-hue))
+
+		l.props = randProps(r, z.props)
+	}
+	return c
+}
+
+func randTrees(r *rand.Rand) []drawItem {
+	trees := make([]drawItem, 2+r.Intn(3))
+	for i := range trees {
+		trees[i] = drawItem{
+			imageName: fmt.Sprintf("tree_%d", r.Intn(2)),
+			dx:        -17 + r.Intn(58),
+			dy:        13 + r.Intn(68),
+		}
+	}
+	sortBackToFront(trees)
+	return trees
+}
+
+func randProps(r *rand.Rand, names []string) []drawItem {
+	if len(names) == 0 {
+		return nil
+	}
+	props := make([]drawItem, r.Intn(4))
+	for i := range props {
+		props[i] = drawItem{
+			imageName: names[r.Intn(len(names))],
+			dx:        -10 + r.Intn(21),
+			dy:        2 + r.Intn(4),
+		}
+	}
+	sortBackToFront(props)
+	return props
+}
+
+// sortBackToFront sorts the items so that the ones further up, which are
+// further away, are drawn first.
+func sortBackToFront(items []drawItem) {
+	slices.SortFunc(items, func(a, b drawItem) int {
+		return b.dy - a.dy
+	})
+}
+
+// fenceDoor returns the image of the i'th part of the fence. There are no doors
+// in the railing along the river.
+func (w *world) fenceDoor(i int) string {
+	if w.lot(i).water {
+		return "railing"
+	}
+	return fmt.Sprintf("fence_door_%d", w.rand(layerFence, i).Intn(3))
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,106 @@
+package sim
+
+import "fmt"
+
+// The street leads through zones that follow each other in the order of
+// zones, over and over again. A new zone starts at every milestone.
+
+// zone describes what the city looks like in a part of the street.
+type zone struct {
+	name string
+	// buildings are the images that lots are built with. Zones without
+	// buildings are all park.
+	buildings []string
+	// parkChance is one in how many lots starts a park, 0 for none.
+	parkChance int
+	// water zones have a river instead of lots and a railing instead of the
+	// fence.
+	water bool
+	props []string
+	// tint is the color of the buildings, every lot varies it a bit.
+	tint Color
+}
+
+var zones = []zone{
+	{
+		name:       "DOWNTOWN",
+		buildings:  []string{"skyscraper_0", "skyscraper_1", "skyscraper_2"},
+		parkChance: 9,
+		props:      []string{"trashcan", "bush_0", "bush_1"},
+		tint:       White,
+	},
+	{
+		name: "CITY PARK",
+	},
+	{
+		name:      "INDUSTRIAL",
+		buildings: []string{"factory_0", "factory_1"},
+		props:     []string{"barrel", "crate", "crate", "trashcan"},
+		tint:      RGB(0.95, 0.9, 0.85),
+	},
+	{
+		name:      "SUBURBS",
+		buildings: []string{"house_0", "house_1"},
+		props:     []string{"mailbox", "bush_0", "bush_1"},
+		tint:      RGB(1, 1, 0.95),
+	},
+	{
+		name:  "BRIDGE",
+		water: true,
+	},
+}
+
+var waterColor = rgb(20, 36, 56)
+
+// bannerDuration is how long the name of a new zone is shown.
+const bannerDuration = 3 * 60
+
+// zoneAt returns the index into zones of the zone at x. Everything before the
+// start of the street belongs to the first zone.
+func (w *world) zoneAt(x int) int {
+	return max(0, floorDiv(x, w.zoneLength)) % len(zones)
+}
+
+// zoneLength is the length of a zone in pixels.
+func (g *Game) zoneLength() int {
+	return max(1, round(g.tuning().ZoneLength/g.tuning().MilesPerPixel))
+}
+
+// updateMilestones counts the milestones that the bike passed and announces
+// each new zone. The milestones are where the zones of the world start, not
+// the distance that the bike rode, which only counts from where the player
+// takes control, so the banner shows up with the new buildings.
+func (g *Game) updateMilestones() {
+	milestone := floorDiv(round(g.BikeX), g.zoneLength())
+	if milestone > g.milestone {
+		g.milestone = milestone
+		g.bannerTimer = bannerDuration
+		g.play(SoundMenuSelect)
+	}
+}
+
+// drawBanner shows the name of the zone that the bike entered at the last
+// milestone.
+func (g *Game) drawBanner(city *world) {
+	if g.bannerTimer <= 0 {
+		return
+	}
+	g.bannerTimer--
+
+	a := min(1, float32(g.bannerTimer)/30, float32(bannerDuration-g.bannerTimer)/30)
+	x, y := float64(g.canvas.w)/2, float64(g.canvas.h)/4
+	outline := RGBA(0, 0, 0, 0.6*a)
+	name := zones[city.zoneAt(round(g.BikeX))].name
+	_, nameH := g.bitmapTextSize(name, 2)
+	g.bitmapText(name, x, y, textStyle{scale: 2, color: RGBA(1, 1, 0.8, a), outline: outline, align: alignCenter})
+	miles := fmt.Sprintf("%.1f MILES", float64(g.milestone*g.zoneLength())*g.tuning().MilesPerPixel)
+	g.bitmapText(miles, x, y+nameH, textStyle{scale: 1, color: RGBA(1, 1, 1, a), outline: outline, align: alignCenter})
+}
+
+// drawWater draws the river in a lot of a water zone.
+func (g *Game) drawWater(x, streetH int) {
+	g.fillRect(x, streetH, lotW, 130, waterColor)
+	for i := range 7 {
+		g.fillRect(x+(i*13)%45, streetH+10+i*17, 4, 1, rgb(45, 70, 100))
+	}
+}
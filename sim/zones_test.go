@@ -0,0 +1,15 @@
+package sim
+
+import "testing"
+
+func TestMilestonesStartZones(t *testing.T) {
+	g := &Game{}
+	city := &world{seed: testSeed, zoneLength: g.zoneLength()}
+	for x := 0; x < 3*city.zoneLength; x += 50 {
+		g.BikeX = float64(x)
+		g.updateMilestones()
+		if zone := city.zoneAt(x); g.milestone%len(zones) != zone {
+			t.Fatalf("the bike is in zone %d at %d, but passed %d milestones", zone, x, g.milestone)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package tuning holds the numbers that define how the game plays. The
+// defaults are in tuning.json, which designers can change to balance the game.
+// A tuning file on disk can override them while the game runs, see File.
+package tuning
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+type Tuning struct {
+	Bike Bike `json:"bike"`
+	Car  Cars `json:"car"`
+	// Pursuer is how the car chases the bike at all difficulty levels.
+	Pursuer Pursuer `json:"pursuer"`
+	// MilesPerPixel is the distance that the bike travels per pixel.
+	MilesPerPixel float64 `json:"miles_per_pixel"`
+	// ZoneLength is the distance in miles between two milestones. Each
+	// milestone starts a new zone of the city.
+	ZoneLength float64 `json:"zone_length"`
+	// DayLength is the distance in miles in which a full day passes.
+	DayLength float64 `json:"day_length"`
+	Weather   Weather `json:"weather"`
+	Intro     Intro   `json:"intro"`
+	Camera    Camera  `json:"camera"`
+	Stamina   Stamina `json:"stamina"`
+	Pickups   Pickups `json:"pickups"`
+	Lanes     Lanes   `json:"lanes"`
+	// Parallax are the layers of scenery behind the city, drawn in order
+	// from back to front.
+	Parallax []ParallaxLayer `json:"parallax"`
+}
+
+// Bike speeds are in pixels per frame.
+type Bike struct {
+	// Decay is the factor on the bike's speed in every frame.
+	Decay float64 `json:"decay"`
+	// PedalBoost is what the bike's speed is divided by for every pedal
+	// stroke.
+	PedalBoost float64 `json:"pedal_boost"`
+	// WrongKeyDecay is the factor on the bike's speed when the player pedals
+	// with the wrong foot.
+	WrongKeyDecay float64 `json:"wrong_key_decay"`
+	MinSpeed      float64 `json:"min_speed"`
+	// MaxSpeed is the bike's top speed without a boost.
+	MaxSpeed float64 `json:"max_speed"`
+	// JumpSpeed is the bike's upward speed when it jumps, JumpGravity is
+	// subtracted from it in every frame in the air.
+	JumpSpeed   float64 `json:"jump_speed"`
+	JumpGravity float64 `json:"jump_gravity"`
+}
+
+// Difficulties are the names of the difficulty levels, from the easiest to
+// the hardest. They differ in how the car chases the bike.
+var Difficulties = []string{"easy", "normal", "hard", "insane"}
+
+// DefaultDifficulty is the difficulty level for players who did not choose
+// one.
+const DefaultDifficulty = "normal"
+
+// Cars has the car's behavior for each difficulty level.
+type Cars struct {
+	Easy   Car `json:"easy"`
+	Normal Car `json:"normal"`
+	Hard   Car `json:"hard"`
+	Insane Car `json:"insane"`
+}
+
+// For returns the car's behavior for the given difficulty level. Unknown
+// levels get the default.
+func (c *Cars) For(difficulty string) Car {
+	switch difficulty {
+	case "easy":
+		return c.Easy
+	case "hard":
+		return c.Hard
+	case "insane":
+		return c.Insane
+	}
+	return c.Normal
+}
+
+// Car speeds are in pixels per frame. The car's speed is blended towards its
+// target speed in every frame, which depends on the bike's speed and on what
+// the car is doing, see Pursuer.
+type Car struct {
+	// CatchUpBlend is the part of the target speed in the blend while the car
+	// is slower than its target.
+	CatchUpBlend float64 `json:"catch_up_blend"`
+	// FallBackBlend is the part of the target speed in the blend while the
+	// car is faster than its target.
+	FallBackBlend float64 `json:"fall_back_blend"`
+	MinSpeed      float64 `json:"min_speed"`
+	// RubberBand is the speed added to the target per pixel that the car is
+	// behind the bike while closing the gap, so it does not lose track of the
+	// bike.
+	RubberBand float64 `json:"rubber_band"`
+	// EscalationFrom is the distance in miles from which on the car gets
+	// faster the further the bike rides.
+	EscalationFrom float64 `json:"escalation_from"`
+	// Escalation is the part of the bike's speed that is added to the target
+	// per mile after EscalationFrom.
+	Escalation float64 `json:"escalation"`
+}
+
+// Pursuer has the distances, in pixels, at which the car changes its behavior
+// and what it does then. Durations are in frames.
+type Pursuer struct {
+	// CloseGapDistance is how far behind the bike the car speeds up to close
+	// the gap, by CloseGapBoost times the bike's speed.
+	CloseGapDistance float64 `json:"close_gap_distance"`
+	CloseGapBoost    float64 `json:"close_gap_boost"`
+	// LungeDistance is how close behind the bike the car lunges at it, adding
+	// LungeSpeed to its target speed.
+	LungeDistance float64 `json:"lunge_distance"`
+	LungeSpeed    float64 `json:"lunge_speed"`
+	LungeDuration int     `json:"lunge_duration"`
+	// LungeCooldown is the time after a lunge starts before the next one.
+	LungeCooldown int `json:"lunge_cooldown"`
+	// BrakeDrop is the part of the bike's speed that it has to lose in a single
+	// frame to make the car fall back.
+	BrakeDrop float64 `json:"brake_drop"`
+	// FallBackFactor is the factor on the bike's speed that the car brakes
+	// to, blended with BrakeBlend per frame.
+	FallBackFactor   float64 `json:"fall_back_factor"`
+	BrakeBlend       float64 `json:"brake_blend"`
+	FallBackDuration int     `json:"fall_back_duration"`
+	// ZoneSpeedup is the part of the bike's speed that the car gets faster
+	// per milestone.
+	ZoneSpeedup float64 `json:"zone_speedup"`
+}
+
+// Weather changes every Length miles. Rain, fog and snow make the wheels slip,
+// the grips are the factors on the speed gained per pedal stroke.
+type Weather struct {
+	Length   float64 `json:"length"`
+	RainGrip float64 `json:"rain_grip"`
+	FogGrip  float64 `json:"fog_grip"`
+	SnowGrip float64 `json:"snow_grip"`
+}
+
+// Intro is the camera zooming from the sky into the street.
+type Intro struct {
+	// Zoom is the zoom that the intro starts at, in canvas pixels per pixel.
+	// While playing, the zoom is 1.
+	Zoom float64 `json:"zoom"`
+	// ZoomSpeed is the part of the zoom that is done per frame.
+	ZoomSpeed float64 `json:"zoom_speed"`
+}
+
+// Camera is how the view follows the bike while playing.
+type Camera struct {
+	// Follow is the part of the way to its target that the camera moves per
+	// frame.
+	Follow float64 `json:"follow"`
+	// LookAhead is how far in front of the bike the camera looks, in pixels
+	// per pixel per frame of the bike's speed.
+	LookAhead float64 `json:"look_ahead"`
+	// CrashShake is how far, in pixels, the view shakes when the bike
+	// crashes.
+	CrashShake float64 `json:"crash_shake"`
+	// ShakeDecay is the factor on the shake in every frame.
+	ShakeDecay float64 `json:"shake_decay"`
+}
+
+// Stamina is drained by pedaling faster than the relaxed cadence and
+// regenerates all the time, faster while coasting. An exhausted rider gains
+// less speed per pedal stroke.
+//
+// The defaults are balanced for this endurance from full stamina: pedaling at
+// the relaxed cadence, a stroke every 12 frames, can go on forever. A stroke
+// every 7 frames, which outruns the car, lasts about 40 seconds, and all-out
+// sprinting, a stroke every 4 frames, about 10 seconds. Coasting refills the
+// stamina in about 4 seconds.
+type Stamina struct {
+	// PedalCost is the stamina, out of 1, that a pedal stroke costs at a
+	// relaxed cadence.
+	PedalCost float64 `json:"pedal_cost"`
+	// RelaxedCadence is the number of frames between two pedal strokes from
+	// which on they cost only PedalCost. Faster strokes cost more.
+	RelaxedCadence int `json:"relaxed_cadence"`
+	// MaxCadenceFactor limits the factor on PedalCost for fast strokes.
+	MaxCadenceFactor float64 `json:"max_cadence_factor"`
+	// Regen is the stamina regenerated per frame. It makes up for the cost
+	// of pedaling at the relaxed cadence.
+	Regen float64 `json:"regen"`
+	// CoastDelay is the number of frames without pedaling after which the
+	// rider is coasting and regenerates CoastRegen more per frame.
+	CoastDelay int     `json:"coast_delay"`
+	CoastRegen float64 `json:"coast_regen"`
+	// RecoverAt is the stamina that an exhausted rider needs to regain before
+	// pedaling at full strength again.
+	RecoverAt float64 `json:"recover_at"`
+	// ExhaustedGain is the factor on the speed gained per stroke while
+	// exhausted.
+	ExhaustedGain float64 `json:"exhausted_gain"`
+}
+
+// Pickups are what the pickups do. Speeds are in pixels per frame, durations
+// in frames.
+type Pickups struct {
+	// BoostedMaxSpeed is the bike's top speed while it is boosted.
+	BoostedMaxSpeed float64 `json:"boosted_max_speed"`
+	// BoostKick is the factor on the bike's speed when picking up a boost.
+	BoostKick     float64 `json:"boost_kick"`
+	BoostDuration int     `json:"boost_duration"`
+	// SlowCarFactor is the factor on the car's speed while it is slowed down.
+	SlowCarFactor   float64 `json:"slow_car_factor"`
+	SlowCarDuration int     `json:"slow_car_duration"`
+	// ShieldKnockBack is how far, in pixels, the car is pushed behind the
+	// bike when it hits the shield.
+	ShieldKnockBack float64 `json:"shield_knock_back"`
+}
+
+// Lanes is how the bike and the car change lanes.
+type Lanes struct {
+	// ChangeSpeed is how far, in pixels, the bike and the car move up or
+	// down per frame while changing lanes.
+	ChangeSpeed float64 `json:"change_speed"`
+	// CarReactionTime is the number of frames that the car waits behind the
+	// bike before following it into another lane.
+	CarReactionTime int `json:"car_reaction_time"`
+	// CarBesideFactor is the factor on the bike's speed that the car slows
+	// down to while it is beside the bike in another lane.
+	CarBesideFactor float64 `json:"car_beside_factor"`
+}
+
+// ParallaxLayer is scenery behind the city that scrolls slower than the
+// street, which makes it look further away. The layer has a spot every Spacing
+// pixels, each spot has an item that is picked at random from the ranges
+// below.
+type ParallaxLayer struct {
+	// Scroll is how fast the layer moves with the camera, from 0 for not at
+	// all to 1 for as fast as the street.
+	Scroll float64 `json:"scroll"`
+	// Y is the height of the layer above the top of the street.
+	Y       int `json:"y"`
+	Spacing int `json:"spacing"`
+	// DX and DY are the smallest and largest offset of an item from its
+	// spot, in pixels. Positive DY is up.
+	DX [2]int `json:"dx"`
+	DY [2]int `json:"dy"`
+	// Images are the images that the items are picked from. Layers without
+	// images have stars, which are a single pixel of the window.
+	Images []string `json:"images"`
+	// Tint is the red, green and blue of the items, from 0 to 1. Each item
+	// adds up to TintVariation to all three.
+	Tint          [3]float32 `json:"tint"`
+	TintVariation float32    `json:"tint_variation"`
+}
+
+//go:embed tuning.json
+var defaultJSON []byte
+
+// Default returns the tuning from the embedded tuning.json.
+func Default() *Tuning {
+	var t Tuning
+	if err := json.Unmarshal(defaultJSON, &t); err != nil {
+		panic("invalid embedded tuning.json: " + err.Error())
+	}
+	if err := t.validate(); err != nil {
+		panic("invalid embedded tuning.json: " + err.Error())
+	}
+	return &t
+}
+
+// Load reads the tuning from the given file. Values that are missing in the
+// file keep their defaults, so the file only needs to contain the numbers that
+// are changed. It fails for numbers that would break the game, like lengths
+// of 0 that the game divides by.
+func Load(path string) (*Tuning, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t := Default()
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// validate checks the lengths, durations and other numbers that the game
+// divides by, and the ranges of the parallax layers.
+func (t *Tuning) validate() error {
+	positive := []struct {
+		name  string
+		value float64
+	}{
+		{"miles_per_pixel", t.MilesPerPixel},
+		{"zone_length", t.ZoneLength},
+		{"day_length", t.DayLength},
+		{"weather.length", t.Weather.Length},
+		{"bike.pedal_boost", t.Bike.PedalBoost},
+		{"intro.zoom", t.Intro.Zoom},
+		{"intro.zoom_speed", t.Intro.ZoomSpeed},
+		{"stamina.relaxed_cadence", float64(t.Stamina.RelaxedCadence)},
+		{"pickups.boost_duration", float64(t.Pickups.BoostDuration)},
+		{"pickups.slow_car_duration", float64(t.Pickups.SlowCarDuration)},
+		{"lanes.change_speed", t.Lanes.ChangeSpeed},
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			return fmt.Errorf("tuning: %s must be greater than 0 but is %v", p.name, p.value)
+		}
+	}
+	for i, l := range t.Parallax {
+		switch {
+		case l.Spacing <= 0:
+			return fmt.Errorf("tuning: parallax layer %d: spacing must be greater than 0 but is %d", i, l.Spacing)
+		case l.DX[0] > l.DX[1]:
+			return fmt.Errorf("tuning: parallax layer %d: dx %v goes from high to low", i, l.DX)
+		case l.DY[0] > l.DY[1]:
+			return fmt.Errorf("tuning: parallax layer %d: dy %v goes from high to low", i, l.DY)
+		}
+	}
+	return nil
+}
+
+// File is a tuning file on disk that is reloaded when it changes.
+type File struct {
+	Path    string
+	modTime time.Time
+}
+
+// Reload loads the file if it was changed since the last call. It returns nil
+// if the file did not change. A missing file results in the defaults.
+func (f *File) Reload() (*Tuning, error) {
+	info, err := os.Stat(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if f.modTime.IsZero() {
+			return nil, nil
+		}
+		f.modTime = time.Time{}
+		return Default(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(f.modTime) {
+		return nil, nil
+	}
+	f.modTime = info.ModTime()
+	return Load(f.Path)
+}
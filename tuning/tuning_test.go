@@ -0,0 +1,55 @@
+package tuning
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOverridesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	if err := os.WriteFile(path, []byte(`{"day_length": 5, "bike": {"max_speed": 3}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	tuning, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tuning.DayLength != 5 || tuning.Bike.MaxSpeed != 3 {
+		t.Errorf("the file was not applied: day length %v, top speed %v", tuning.DayLength, tuning.Bike.MaxSpeed)
+	}
+	if want := Default().Bike.Decay; tuning.Bike.Decay != want {
+		t.Errorf("the missing decay is %v, want the default %v", tuning.Bike.Decay, want)
+	}
+}
+
+func TestLoadRejectsBrokenNumbers(t *testing.T) {
+	for _, data := range []string{
+		`{"day_length": 0}`,
+		`{"miles_per_pixel": -1}`,
+		`{"zone_length": 0}`,
+		`{"weather": {"length": 0}}`,
+		`{"pickups": {"boost_duration": 0}}`,
+		`{"parallax": [{"spacing": 0}]}`,
+		`{"parallax": [{"spacing": 10, "dx": [5, -5]}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "tuning.json")
+		if err := os.WriteFile(path, []byte(data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s was loaded", data)
+		}
+	}
+}
+
+func TestReloadKeepsTuningOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tuning.json")
+	if err := os.WriteFile(path, []byte(`{"day_length": 0}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	f := File{Path: path}
+	if tuning, err := f.Reload(); err == nil || tuning != nil {
+		t.Errorf("Reload returned %v, %v for a broken file, want an error and no tuning", tuning, err)
+	}
+}